@@ -22,6 +22,9 @@ resource "hydra_project" "nixpkgs-declarative" {
   enabled      = false
   visible      = true
 
+  # Hydra makes the jobsets from the spec.
+  force_destroy = true
+
   declarative {
     file  = "static-declarative-project/declarative.json"
     type  = "git"
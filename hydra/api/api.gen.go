@@ -143,8 +143,8 @@ type Error struct {
 
 // Evaluations defines model for Evaluations.
 type Evaluations struct {
-	// Evals List of evaluations
-	Evals *[]map[string]JobsetEval `json:"evals,omitempty"`
+	// Evals List of evaluations, latest first
+	Evals *[]JobsetEval `json:"evals,omitempty"`
 
 	// First first list of results
 	First *string `json:"first,omitempty"`
@@ -212,8 +212,8 @@ type Jobset struct {
 	// Schedulingshares how many shares to be allocated to the jobset
 	Schedulingshares *int `json:"schedulingshares,omitempty"`
 
-	// Startime set to the time the latest evaluation started (if one is currently running)
-	Startime *int `json:"startime"`
+	// Starttime set to the time the latest evaluation started (if one is currently running)
+	Starttime *int `json:"starttime"`
 
 	// Triggertime set to the time we were triggered by a push event
 	Triggertime *int `json:"triggertime"`
@@ -370,6 +370,48 @@ type SearchResult struct {
 	Projects *[]Project `json:"projects,omitempty"`
 }
 
+// User defines model for User.
+type User struct {
+	// Emailaddress email address of the user
+	Emailaddress *string `json:"emailaddress,omitempty"`
+
+	// Emailonly when set to true the user only receives email notifications and can't log in
+	Emailonly *bool `json:"emailonly,omitempty"`
+
+	// Fullname full name of the user
+	Fullname *string `json:"fullname,omitempty"`
+
+	// Username name of the user
+	Username *string `json:"username,omitempty"`
+
+	// Userroles roles of the user, e.g. "admin" or "create-projects"
+	Userroles *[]string `json:"userroles,omitempty"`
+}
+
+// UserInput defines model for UserInput.
+type UserInput struct {
+	// Emailaddress email address of the user
+	Emailaddress *string `json:"emailaddress,omitempty"`
+
+	// Emailonly when set to true the user only receives email notifications and can't log in
+	Emailonly *bool `json:"emailonly,omitempty"`
+
+	// Fullname full name of the user
+	Fullname *string `json:"fullname,omitempty"`
+
+	// Password new password of the user, which is required for new users and otherwise left unchanged when unset
+	Password *string `json:"password,omitempty"`
+
+	// Password2 the password again, which must match password
+	Password2 *string `json:"password2,omitempty"`
+
+	// Roles roles of the user, replacing the current ones
+	Roles *[]string `json:"roles,omitempty"`
+
+	// Username name of the new user, when creating one
+	Username *string `json:"username,omitempty"`
+}
+
 // GetApiJobsetsParams defines parameters for GetApiJobsets.
 type GetApiJobsetsParams struct {
 	// Project name of the project
@@ -435,6 +477,12 @@ type PostLoginJSONRequestBody PostLoginJSONBody
 // PutProjectIdJSONRequestBody defines body for PutProjectId for application/json ContentType.
 type PutProjectIdJSONRequestBody PutProjectIdJSONBody
 
+// PutRegisterJSONRequestBody defines body for PutRegister for application/json ContentType.
+type PutRegisterJSONRequestBody = UserInput
+
+// PutUserUsernameJSONRequestBody defines body for PutUserUsername for application/json ContentType.
+type PutUserUsernameJSONRequestBody = UserInput
+
 // RequestEditorFn  is the function signature for the RequestEditor callback function
 type RequestEditorFn func(ctx context.Context, req *http.Request) error
 
@@ -562,8 +610,24 @@ type ClientInterface interface {
 
 	PutProjectId(ctx context.Context, id string, body PutProjectIdJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// PutRegisterWithBody request with any body
+	PutRegisterWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PutRegister(ctx context.Context, body PutRegisterJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// GetSearch request
 	GetSearch(ctx context.Context, params *GetSearchParams, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// DeleteUserUsername request
+	DeleteUserUsername(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// GetUserUsername request
+	GetUserUsername(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PutUserUsernameWithBody request with any body
+	PutUserUsernameWithBody(ctx context.Context, username string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PutUserUsername(ctx context.Context, username string, body PutUserUsernameJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
 }
 
 func (c *Client) Get(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
@@ -794,6 +858,30 @@ func (c *Client) PutProjectId(ctx context.Context, id string, body PutProjectIdJ
 	return c.Client.Do(req)
 }
 
+func (c *Client) PutRegisterWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutRegisterRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutRegister(ctx context.Context, body PutRegisterJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutRegisterRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) GetSearch(ctx context.Context, params *GetSearchParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewGetSearchRequest(c.Server, params)
 	if err != nil {
@@ -806,6 +894,54 @@ func (c *Client) GetSearch(ctx context.Context, params *GetSearchParams, reqEdit
 	return c.Client.Do(req)
 }
 
+func (c *Client) DeleteUserUsername(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDeleteUserUsernameRequest(c.Server, username)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) GetUserUsername(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetUserUsernameRequest(c.Server, username)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutUserUsernameWithBody(ctx context.Context, username string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutUserUsernameRequestWithBody(c.Server, username, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PutUserUsername(ctx context.Context, username string, body PutUserUsernameJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPutUserUsernameRequest(c.Server, username, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 // NewGetRequest generates requests for Get
 func NewGetRequest(server string) (*http.Request, error) {
 	var err error
@@ -1447,6 +1583,46 @@ func NewPutProjectIdRequestWithBody(server string, id string, contentType string
 	return req, nil
 }
 
+// NewPutRegisterRequest calls the generic PutRegister builder with application/json body
+func NewPutRegisterRequest(server string, body PutRegisterJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPutRegisterRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewPutRegisterRequestWithBody generates requests for PutRegister with any type of body
+func NewPutRegisterRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/register")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
 // NewGetSearchRequest generates requests for GetSearch
 func NewGetSearchRequest(server string, params *GetSearchParams) (*http.Request, error) {
 	var err error
@@ -1496,6 +1672,121 @@ func NewGetSearchRequest(server string, params *GetSearchParams) (*http.Request,
 	return req, nil
 }
 
+// NewDeleteUserUsernameRequest generates requests for DeleteUserUsername
+func NewDeleteUserUsernameRequest(server string, username string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "username", runtime.ParamLocationPath, username)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/user/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("DELETE", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewGetUserUsernameRequest generates requests for GetUserUsername
+func NewGetUserUsernameRequest(server string, username string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "username", runtime.ParamLocationPath, username)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/user/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewPutUserUsernameRequest calls the generic PutUserUsername builder with application/json body
+func NewPutUserUsernameRequest(server string, username string, body PutUserUsernameJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPutUserUsernameRequestWithBody(server, username, "application/json", bodyReader)
+}
+
+// NewPutUserUsernameRequestWithBody generates requests for PutUserUsername with any type of body
+func NewPutUserUsernameRequestWithBody(server string, username string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "username", runtime.ParamLocationPath, username)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/user/%s", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
 func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
 	for _, r := range c.RequestEditors {
 		if err := r(ctx, req); err != nil {
@@ -1593,8 +1884,24 @@ type ClientWithResponsesInterface interface {
 
 	PutProjectIdWithResponse(ctx context.Context, id string, body PutProjectIdJSONRequestBody, reqEditors ...RequestEditorFn) (*PutProjectIdResponse, error)
 
+	// PutRegisterWithBodyWithResponse request with any body
+	PutRegisterWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutRegisterResponse, error)
+
+	PutRegisterWithResponse(ctx context.Context, body PutRegisterJSONRequestBody, reqEditors ...RequestEditorFn) (*PutRegisterResponse, error)
+
 	// GetSearchWithResponse request
 	GetSearchWithResponse(ctx context.Context, params *GetSearchParams, reqEditors ...RequestEditorFn) (*GetSearchResponse, error)
+
+	// DeleteUserUsernameWithResponse request
+	DeleteUserUsernameWithResponse(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*DeleteUserUsernameResponse, error)
+
+	// GetUserUsernameWithResponse request
+	GetUserUsernameWithResponse(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*GetUserUsernameResponse, error)
+
+	// PutUserUsernameWithBodyWithResponse request with any body
+	PutUserUsernameWithBodyWithResponse(ctx context.Context, username string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutUserUsernameResponse, error)
+
+	PutUserUsernameWithResponse(ctx context.Context, username string, body PutUserUsernameJSONRequestBody, reqEditors ...RequestEditorFn) (*PutUserUsernameResponse, error)
 }
 
 type GetResponse struct {
@@ -1867,7 +2174,7 @@ func (r PutJobsetProjectIdJobsetIdResponse) StatusCode() int {
 type GetJobsetProjectIdJobsetIdEvalsResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
-	JSON200      *[]Evaluations
+	JSON200      *Evaluations
 	JSON404      *Error
 }
 
@@ -2017,6 +2324,29 @@ func (r PutProjectIdResponse) StatusCode() int {
 	return 0
 }
 
+type PutRegisterResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON403      *Error
+	JSON500      *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r PutRegisterResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PutRegisterResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 type GetSearchResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
@@ -2043,6 +2373,77 @@ func (r GetSearchResponse) StatusCode() int {
 	return 0
 }
 
+type DeleteUserUsernameResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON404      *Error
+	JSON500      *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r DeleteUserUsernameResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DeleteUserUsernameResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type GetUserUsernameResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *User
+	JSON403      *Error
+	JSON404      *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r GetUserUsernameResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetUserUsernameResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PutUserUsernameResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON403      *Error
+	JSON404      *Error
+	JSON500      *Error
+}
+
+// Status returns HTTPResponse.Status
+func (r PutUserUsernameResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PutUserUsernameResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 // GetWithResponse request returning *GetResponse
 func (c *ClientWithResponses) GetWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*GetResponse, error) {
 	rsp, err := c.Get(ctx, reqEditors...)
@@ -2211,6 +2612,23 @@ func (c *ClientWithResponses) PutProjectIdWithResponse(ctx context.Context, id s
 	return ParsePutProjectIdResponse(rsp)
 }
 
+// PutRegisterWithBodyWithResponse request with arbitrary body returning *PutRegisterResponse
+func (c *ClientWithResponses) PutRegisterWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutRegisterResponse, error) {
+	rsp, err := c.PutRegisterWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutRegisterResponse(rsp)
+}
+
+func (c *ClientWithResponses) PutRegisterWithResponse(ctx context.Context, body PutRegisterJSONRequestBody, reqEditors ...RequestEditorFn) (*PutRegisterResponse, error) {
+	rsp, err := c.PutRegister(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutRegisterResponse(rsp)
+}
+
 // GetSearchWithResponse request returning *GetSearchResponse
 func (c *ClientWithResponses) GetSearchWithResponse(ctx context.Context, params *GetSearchParams, reqEditors ...RequestEditorFn) (*GetSearchResponse, error) {
 	rsp, err := c.GetSearch(ctx, params, reqEditors...)
@@ -2220,6 +2638,41 @@ func (c *ClientWithResponses) GetSearchWithResponse(ctx context.Context, params
 	return ParseGetSearchResponse(rsp)
 }
 
+// DeleteUserUsernameWithResponse request returning *DeleteUserUsernameResponse
+func (c *ClientWithResponses) DeleteUserUsernameWithResponse(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*DeleteUserUsernameResponse, error) {
+	rsp, err := c.DeleteUserUsername(ctx, username, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeleteUserUsernameResponse(rsp)
+}
+
+// GetUserUsernameWithResponse request returning *GetUserUsernameResponse
+func (c *ClientWithResponses) GetUserUsernameWithResponse(ctx context.Context, username string, reqEditors ...RequestEditorFn) (*GetUserUsernameResponse, error) {
+	rsp, err := c.GetUserUsername(ctx, username, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetUserUsernameResponse(rsp)
+}
+
+// PutUserUsernameWithBodyWithResponse request with arbitrary body returning *PutUserUsernameResponse
+func (c *ClientWithResponses) PutUserUsernameWithBodyWithResponse(ctx context.Context, username string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PutUserUsernameResponse, error) {
+	rsp, err := c.PutUserUsernameWithBody(ctx, username, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutUserUsernameResponse(rsp)
+}
+
+func (c *ClientWithResponses) PutUserUsernameWithResponse(ctx context.Context, username string, body PutUserUsernameJSONRequestBody, reqEditors ...RequestEditorFn) (*PutUserUsernameResponse, error) {
+	rsp, err := c.PutUserUsername(ctx, username, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePutUserUsernameResponse(rsp)
+}
+
 // ParseGetResponse parses an HTTP response from a GetWithResponse call
 func ParseGetResponse(rsp *http.Response) (*GetResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -2573,7 +3026,7 @@ func ParseGetJobsetProjectIdJobsetIdEvalsResponse(rsp *http.Response) (*GetJobse
 
 	switch {
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
-		var dest []Evaluations
+		var dest Evaluations
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
 			return nil, err
 		}
@@ -2773,6 +3226,39 @@ func ParsePutProjectIdResponse(rsp *http.Response) (*PutProjectIdResponse, error
 	return response, nil
 }
 
+// ParsePutRegisterResponse parses an HTTP response from a PutRegisterWithResponse call
+func ParsePutRegisterResponse(rsp *http.Response) (*PutRegisterResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PutRegisterResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParseGetSearchResponse parses an HTTP response from a GetSearchWithResponse call
 func ParseGetSearchResponse(rsp *http.Response) (*GetSearchResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -2808,3 +3294,116 @@ func ParseGetSearchResponse(rsp *http.Response) (*GetSearchResponse, error) {
 
 	return response, nil
 }
+
+// ParseDeleteUserUsernameResponse parses an HTTP response from a DeleteUserUsernameWithResponse call
+func ParseDeleteUserUsernameResponse(rsp *http.Response) (*DeleteUserUsernameResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DeleteUserUsernameResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseGetUserUsernameResponse parses an HTTP response from a GetUserUsernameWithResponse call
+func ParseGetUserUsernameResponse(rsp *http.Response) (*GetUserUsernameResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetUserUsernameResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest User
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParsePutUserUsernameResponse parses an HTTP response from a PutUserUsernameWithResponse call
+func ParsePutUserUsernameResponse(rsp *http.Response) (*PutUserUsernameResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PutUserUsernameResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 403:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON403 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 500:
+		var dest Error
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON500 = &dest
+
+	}
+
+	return response, nil
+}
@@ -0,0 +1,262 @@
+package hydra
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/net/html"
+
+	hydraclient "terraform-provider-hydra/hydra/client"
+)
+
+// capability tells whether Hydra supports a feature, as far as the provider
+// could find out.
+type capability int
+
+const (
+	capabilityUnknown capability = iota
+	capabilitySupported
+	capabilityUnsupported
+)
+
+// String returns how the capability is reported in the logs.
+func (c capability) String() string {
+	switch c {
+	case capabilitySupported:
+		return "supported"
+	case capabilityUnsupported:
+		return "unsupported"
+	default:
+		return "unknown"
+	}
+}
+
+// hydraCapabilities describes the Hydra the provider talks to. Only what's
+// known to be unsupported is rejected: when in doubt, the provider lets Hydra
+// decide.
+type hydraCapabilities struct {
+	// As shown at the bottom of every page, e.g. "0.1.20230825.04f9db5".
+	Version    string
+	NixVersion string
+
+	// Whether `PUT /project/{id}` and `PUT /jobset/{project}/{jobset}` accept
+	// JSON.
+	JSONAPI capability
+	// Whether jobsets can be flakes.
+	Flakes capability
+	// Whether projects and jobsets have `enable_dynamic_run_command`.
+	DynamicRunCommand capability
+
+	// The types of inputs Hydra has plugins for, or nil if unknown.
+	InputTypes []string
+}
+
+// Hydra's footer reads e.g. "Hydra 0.1.20230825.04f9db5 (using nix-2.17.0)."
+var versionRe = regexp.MustCompile(`Hydra\s+(\S+)\s+\(using\s+([^)]+)\)`)
+
+// name refers to the Hydra in errors.
+func (c *hydraCapabilities) name() string {
+	if c.Version == "" {
+		return "this Hydra"
+	}
+	return "Hydra " + c.Version
+}
+
+// supportsInputType reports whether Hydra is known to support the input type,
+// or doesn't tell.
+func (c *hydraCapabilities) supportsInputType(inputType string) bool {
+	if c.InputTypes == nil {
+		return true
+	}
+
+	for _, t := range c.InputTypes {
+		if t == inputType {
+			return true
+		}
+	}
+	return false
+}
+
+// capabilities returns what the Hydra supports, which is found out once, when
+// the provider is configured (or the first time it's needed), and then
+// remembered for the lifetime of the provider.
+func (m *providerMeta) capabilities(ctx context.Context) *hydraCapabilities {
+	m.capsOnce.Do(func() {
+		m.caps = probeCapabilities(ctx, m.client)
+
+		tflog.Info(ctx, "Detected Hydra capabilities", map[string]interface{}{
+			"version":             m.caps.Version,
+			"nix_version":         m.caps.NixVersion,
+			"json_api":            m.caps.JSONAPI.String(),
+			"flakes":              m.caps.Flakes.String(),
+			"dynamic_run_command": m.caps.DynamicRunCommand.String(),
+			"input_types":         strings.Join(m.caps.InputTypes, ","),
+		})
+	})
+
+	return m.caps
+}
+
+// probeCapabilities finds out what Hydra supports from the projects it lists,
+// and the form for creating a jobset, which lists the types of inputs and has
+// a field for each jobset setting. The version is only reported: releases are
+// too loosely dated to tell what they support, and a wrong guess would fail
+// plans that Hydra would accept. Anything that fails just leaves the
+// capabilities it would have told unknown.
+func probeCapabilities(ctx context.Context, client *hydraclient.Client) *hydraCapabilities {
+	caps := &hydraCapabilities{}
+
+	if page, err := client.GetPage(ctx, "/"); err == nil {
+		caps.Version, caps.NixVersion = parseVersion(page)
+	} else {
+		tflog.Debug(ctx, "Failed to fetch Hydra's version", map[string]interface{}{"error": err.Error()})
+	}
+
+	// Hydras without the JSON API answer requests for JSON with their pages.
+	// The project JSON of Hydras that support it always has the field.
+	var project string
+	if resp, err := client.GetWithResponse(ctx); err == nil {
+		caps.JSONAPI = jsonCapability(resp.HTTPResponse)
+
+		if resp.JSON200 != nil {
+			for _, p := range *resp.JSON200 {
+				if p.EnableDynamicRunCommand != nil {
+					caps.DynamicRunCommand = capabilitySupported
+				}
+				if p.Name != nil && project == "" {
+					project = *p.Name
+				}
+			}
+		}
+	}
+
+	// Only project owners and admins get to see the form.
+	if project != "" {
+		page, err := client.GetPage(ctx, "/project/"+url.PathEscape(project)+"/create-jobset")
+		if err == nil {
+			parseJobsetForm(page, caps)
+		} else {
+			tflog.Debug(ctx, "Failed to fetch Hydra's jobset form", map[string]interface{}{"error": err.Error()})
+		}
+	}
+
+	return caps
+}
+
+// jsonCapability tells whether Hydra supports JSON from its response to a
+// request for JSON, if it was successful.
+func jsonCapability(resp *http.Response) capability {
+	if resp == nil || resp.StatusCode != http.StatusOK {
+		return capabilityUnknown
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if strings.Contains(mediaType, "json") {
+		return capabilitySupported
+	}
+	return capabilityUnsupported
+}
+
+// parseVersion extracts the versions of Hydra and Nix from the footer of a
+// Hydra page.
+func parseVersion(page []byte) (string, string) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return "", ""
+	}
+
+	var footer string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "footer" {
+			footer = strings.Join(strings.Fields(hydraclient.TextOf(n)), " ")
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	if m := versionRe.FindStringSubmatch(footer); m != nil {
+		return m[1], strings.TrimSpace(m[2])
+	}
+	return "", ""
+}
+
+// parseJobsetForm reads the capabilities off Hydra's form for creating a
+// jobset: the options of the input type selections, the flake option of the
+// jobset type, and the checkbox for dynamic RunCommand. A missing field doesn't
+// overrule what Hydra already showed it supports.
+func parseJobsetForm(page []byte, caps *hydraCapabilities) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return
+	}
+
+	inputTypes := make(map[string]bool)
+	var isForm, flakes, dynamicRunCommand bool
+
+	var walk func(n *html.Node, inputType bool)
+	walk = func(n *html.Node, inputType bool) {
+		if n.Type == html.ElementNode {
+			name := attr(n, "name")
+			switch {
+			case n.Data == "select" && strings.HasPrefix(name, "input-") && strings.HasSuffix(name, "-type"):
+				isForm = true
+				inputType = true
+			case n.Data == "option" && inputType:
+				if value := attr(n, "value"); value != "" {
+					inputTypes[value] = true
+				}
+			case n.Data == "input" && name == "type" && attr(n, "value") == "1":
+				flakes = true
+			case n.Data == "input" && name == "enable_dynamic_run_command":
+				dynamicRunCommand = true
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, inputType)
+		}
+	}
+	walk(doc, false)
+
+	// Anything else isn't the form, e.g. a login page.
+	if !isForm {
+		return
+	}
+
+	caps.InputTypes = make([]string, 0, len(inputTypes))
+	for t := range inputTypes {
+		caps.InputTypes = append(caps.InputTypes, t)
+	}
+	sort.Strings(caps.InputTypes)
+
+	caps.Flakes = formCapability(caps.Flakes, flakes)
+	caps.DynamicRunCommand = formCapability(caps.DynamicRunCommand, dynamicRunCommand)
+}
+
+// formCapability tells whether Hydra supports a feature, given whether its
+// form has the field for it.
+func formCapability(known capability, field bool) capability {
+	if field || known == capabilitySupported {
+		return capabilitySupported
+	}
+	return capabilityUnsupported
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
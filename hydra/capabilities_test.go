@@ -0,0 +1,157 @@
+package hydra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testHydraFooter = `<footer class="navbar">
+  <hr />
+  <small>
+    <em><a href="http://nixos.org/hydra" target="_blank" class="squiggle">Hydra</a> %s (using nix-2.17.0).</em>
+  </small>
+</footer>`
+
+const testJobsetForm = `<html><body><form>
+  <div class="btn-group btn-group-toggle" data-toggle="buttons">
+    <input type="radio" name="type" value="0" />
+    %s
+  </div>
+  <tr class="input-template">
+    <td>
+      <select class="custom-select" name="input-template-type">
+        <option value="boolean">Boolean</option>
+        <option value="git">Git checkout</option>
+        <option value="string">String value</option>
+      </select>
+    </td>
+  </tr>
+  %s
+</form></body></html>`
+
+// capabilitiesHydra serves what the provider probes for the capabilities.
+func capabilitiesHydra(t *testing.T, version string, form string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/" && strings.Contains(r.Header.Get("Accept"), "html"):
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprintf(w, "<html><body>"+testHydraFooter+"</body></html>", version)
+		case r.URL.Path == "/":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[{"name": "nixpkgs", "jobsets": []}]`)
+		case r.URL.Path == "/project/nixpkgs/create-jobset" && form != "":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, form)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"error": "This page requires you to sign in."}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestProbeCapabilities_form(t *testing.T) {
+	form := fmt.Sprintf(testJobsetForm,
+		`<input type="radio" name="type" value="1" />`,
+		`<input type="checkbox" name="enable_dynamic_run_command" />`)
+	server := capabilitiesHydra(t, "0.1.20230825.04f9db5", form)
+
+	caps := testMeta(t, server.URL).capabilities(context.Background())
+
+	if caps.Version != "0.1.20230825.04f9db5" || caps.NixVersion != "nix-2.17.0" {
+		t.Errorf("unexpected versions %q and %q", caps.Version, caps.NixVersion)
+	}
+	if caps.JSONAPI != capabilitySupported || caps.Flakes != capabilitySupported || caps.DynamicRunCommand != capabilitySupported {
+		t.Errorf("expected everything to be supported, got %+v", caps)
+	}
+	if want := []string{"boolean", "git", "string"}; !reflect.DeepEqual(caps.InputTypes, want) {
+		t.Errorf("expected input types %v, got %v", want, caps.InputTypes)
+	}
+	if caps.supportsInputType("svn") {
+		t.Errorf("expected svn inputs to be unsupported")
+	}
+}
+
+func TestProbeCapabilities_formWithoutFeatures(t *testing.T) {
+	// The form is what counts, even if the version says otherwise.
+	server := capabilitiesHydra(t, "0.1.20230825.04f9db5", fmt.Sprintf(testJobsetForm, "", ""))
+
+	caps := testMeta(t, server.URL).capabilities(context.Background())
+
+	if caps.Flakes != capabilityUnsupported || caps.DynamicRunCommand != capabilityUnsupported {
+		t.Errorf("expected flakes and dynamic RunCommand to be unsupported, got %+v", caps)
+	}
+}
+
+func TestProbeCapabilities_projectOverForm(t *testing.T) {
+	// The project JSON shows dynamic RunCommand, which a form without the
+	// checkbox doesn't overrule.
+	form := fmt.Sprintf(testJobsetForm, "", "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/" && strings.Contains(r.Header.Get("Accept"), "html"):
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, "<html><body></body></html>")
+		case r.URL.Path == "/":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `[{"name": "nixpkgs", "enable_dynamic_run_command": false}]`)
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, form)
+		}
+	}))
+	defer server.Close()
+
+	caps := testMeta(t, server.URL).capabilities(context.Background())
+
+	if caps.DynamicRunCommand != capabilitySupported {
+		t.Errorf("expected dynamic RunCommand to be supported, got %s", caps.DynamicRunCommand)
+	}
+	if caps.Flakes != capabilityUnsupported {
+		t.Errorf("expected flakes to be unsupported, got %s", caps.Flakes)
+	}
+}
+
+func TestProbeCapabilities_version(t *testing.T) {
+	// Without the form, the version alone doesn't tell what Hydra supports.
+	for _, version := range []string{"0.1.20191108.4779757", "0.1.20230825.04f9db5", "0.1pre1234_abcdef"} {
+		t.Run(version, func(t *testing.T) {
+			server := capabilitiesHydra(t, version, "")
+
+			caps := testMeta(t, server.URL).capabilities(context.Background())
+
+			if caps.Version != version {
+				t.Errorf("expected version %q, got %q", version, caps.Version)
+			}
+			if caps.Flakes != capabilityUnknown || caps.DynamicRunCommand != capabilityUnknown {
+				t.Errorf("expected flakes and dynamic RunCommand to be unknown, got %s and %s",
+					caps.Flakes, caps.DynamicRunCommand)
+			}
+			if caps.JSONAPI != capabilitySupported {
+				t.Errorf("expected the JSON API to be supported, got %s", caps.JSONAPI)
+			}
+			if caps.InputTypes != nil || !caps.supportsInputType("svn") {
+				t.Errorf("expected the input types to be unknown, got %v", caps.InputTypes)
+			}
+		})
+	}
+}
+
+func TestProbeCapabilities_unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	caps := testMeta(t, server.URL).capabilities(context.Background())
+
+	if caps.Version != "" || caps.JSONAPI != capabilityUnknown || caps.Flakes != capabilityUnknown {
+		t.Errorf("expected nothing to be known, got %+v", caps)
+	}
+}
@@ -0,0 +1,187 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+// cache holds what the Client read from Hydra for the lifetime of the provider
+// process, so that refreshing many resources doesn't fetch the same objects
+// over and over. It is prefetched with Hydra's list endpoints, which return
+// all projects (`GET /`) and an overview of all jobsets of a project
+// (`GET /api/jobsets?project=`) at once.
+//
+// As Hydra leaves hidden projects and jobsets out of those lists for most
+// users, the lists only tell what exists, and everything else is asked for
+// individually.
+type cache struct {
+	mu sync.Mutex
+
+	projects map[string]*api.Project
+	jobsets  map[string]*api.Jobset
+
+	// The names of the jobsets listed by the overview of each project.
+	overviews map[string]map[string]bool
+
+	// gen is incremented on every invalidation, so that the results of reads
+	// that raced with a write aren't cached.
+	gen uint64
+
+	// Held while fetching the list of projects, so that concurrent reads wait
+	// for it instead of all fetching it at the same time.
+	listMu sync.Mutex
+	listed bool // Whether the list of projects was fetched already.
+}
+
+func jobsetKey(project, jobset string) string {
+	return project + "/" + jobset
+}
+
+func (c *cache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.gen
+}
+
+func (c *cache) project(id string) (*api.Project, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.projects[id]
+	return p, ok
+}
+
+func (c *cache) putProject(gen uint64, id string, p *api.Project) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+
+	if c.projects == nil {
+		c.projects = make(map[string]*api.Project)
+	}
+	c.projects[id] = p
+}
+
+func (c *cache) jobset(project, jobset string) (*api.Jobset, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	j, ok := c.jobsets[jobsetKey(project, jobset)]
+	return j, ok
+}
+
+func (c *cache) putJobset(gen uint64, project, jobset string, j *api.Jobset) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+
+	if c.jobsets == nil {
+		c.jobsets = make(map[string]*api.Jobset)
+	}
+	c.jobsets[jobsetKey(project, jobset)] = j
+}
+
+// overview returns the names of the jobsets in the overview of the project,
+// or nil if it wasn't fetched yet.
+func (c *cache) overview(project string) map[string]bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.overviews[project]
+}
+
+func (c *cache) putOverview(gen uint64, project string, names map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+
+	if c.overviews == nil {
+		c.overviews = make(map[string]map[string]bool)
+	}
+	c.overviews[project] = names
+}
+
+// invalidate forgets everything about the projects and their jobsets, after
+// they were (or may have been) changed.
+func (c *cache) invalidate(projects ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+
+	for _, project := range projects {
+		delete(c.projects, project)
+		delete(c.overviews, project)
+
+		prefix := project + "/"
+		for key := range c.jobsets {
+			if strings.HasPrefix(key, prefix) {
+				delete(c.jobsets, key)
+			}
+		}
+	}
+}
+
+// prefetchProjects fills the cache with the list of all projects. This is only
+// attempted once: if it fails, the projects are simply fetched one by one.
+func (c *Client) prefetchProjects(ctx context.Context) {
+	c.cache.listMu.Lock()
+	defer c.cache.listMu.Unlock()
+
+	if c.cache.listed {
+		return
+	}
+	c.cache.listed = true
+
+	gen := c.cache.generation()
+
+	resp, err := c.GetWithResponse(ctx)
+	if err != nil || resp.JSON200 == nil {
+		return
+	}
+
+	for i := range *resp.JSON200 {
+		project := &(*resp.JSON200)[i]
+		if project.Name != nil {
+			c.cache.putProject(gen, *project.Name, project)
+		}
+	}
+}
+
+// prefetchOverview fills the cache with the names of the jobsets of the
+// project, unless they already are.
+func (c *Client) prefetchOverview(ctx context.Context, project string) map[string]bool {
+	if names := c.cache.overview(project); names != nil {
+		return names
+	}
+
+	gen := c.cache.generation()
+
+	resp, err := c.GetApiJobsetsWithResponse(ctx, &api.GetApiJobsetsParams{Project: &project})
+	if err != nil || resp.JSON200 == nil {
+		return nil
+	}
+
+	names := make(map[string]bool, len(*resp.JSON200))
+	for _, jobset := range *resp.JSON200 {
+		if jobset.Name != nil {
+			names[*jobset.Name] = true
+		}
+	}
+	c.cache.putOverview(gen, project, names)
+
+	return names
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+// fakeHydra serves a project `nixpkgs` with the jobset `trunk` (and a hidden
+// project `secret` that isn't listed), and records the requests it gets.
+type fakeHydra struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (f *fakeHydra) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.RequestURI())
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /":
+		fmt.Fprint(w, `[{"name": "nixpkgs", "jobsets": ["trunk"]}, {"name": "hydra", "jobsets": []}]`)
+	case "GET /project/nixpkgs":
+		fmt.Fprint(w, `{"name": "nixpkgs", "jobsets": ["trunk"]}`)
+	case "GET /project/secret":
+		fmt.Fprint(w, `{"name": "secret", "hidden": true}`)
+	case "GET /api/jobsets":
+		fmt.Fprint(w, `[{"name": "trunk"}]`)
+	case "GET /jobset/nixpkgs/trunk":
+		fmt.Fprint(w, `{"name": "trunk", "project": "nixpkgs"}`)
+	case "PUT /project/nixpkgs", "PUT /jobset/nixpkgs/trunk":
+		fmt.Fprint(w, `{"redirect": "/"}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": "Not found."}`)
+	}
+}
+
+// take returns the requests made since the last call.
+func (f *fakeHydra) take() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	requests := f.requests
+	f.requests = nil
+	return requests
+}
+
+func expectRequests(t *testing.T, fake *fakeHydra, expected ...string) {
+	t.Helper()
+
+	if requests := fake.take(); !reflect.DeepEqual(requests, expected) {
+		t.Errorf("expected requests %q, got %q", expected, requests)
+	}
+}
+
+func TestClient_cachesProjects(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeHydra{}
+	c := newTestClient(t, fake.ServeHTTP)
+
+	for _, name := range []string{"nixpkgs", "hydra", "nixpkgs"} {
+		if _, err := c.GetProject(ctx, name); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	expectRequests(t, fake, "GET /")
+
+	// Hidden projects aren't listed, but exist nonetheless.
+	if _, err := c.GetProject(ctx, "secret"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expectRequests(t, fake, "GET /project/secret")
+
+	// Writes invalidate the project, without fetching all projects again.
+	if err := c.UpdateProject(ctx, "nixpkgs", api.PutProjectIdJSONRequestBody{}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := c.GetProject(ctx, "nixpkgs"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expectRequests(t, fake, "PUT /project/nixpkgs", "GET /project/nixpkgs")
+}
+
+func TestClient_cachesJobsets(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeHydra{}
+	c := newTestClient(t, fake.ServeHTTP)
+
+	for _, name := range []string{"trunk", "staging"} {
+		if _, err := c.JobsetExists(ctx, "nixpkgs", name); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	// The overview doesn't list hidden jobsets, so it can only tell which
+	// jobsets exist.
+	expectRequests(t, fake, "GET /api/jobsets?project=nixpkgs", "GET /jobset/nixpkgs/staging")
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetJobset(ctx, "nixpkgs", "trunk"); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	expectRequests(t, fake, "GET /jobset/nixpkgs/trunk")
+
+	if err := c.UpdateJobset(ctx, "nixpkgs", "trunk", api.PutJobsetProjectIdJobsetIdJSONRequestBody{}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := c.GetJobset(ctx, "nixpkgs", "trunk"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expectRequests(t, fake, "PUT /jobset/nixpkgs/trunk", "GET /jobset/nixpkgs/trunk")
+}
@@ -0,0 +1,318 @@
+// Package client wraps the generated Hydra API client, turning every response
+// other than the expected one into an *Error.
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+// Client is a Hydra API client. The generated methods remain available for
+// requests the Client doesn't (yet) have a method for, but unlike those, the
+// Client's methods cache what they read, and those that change a project or its
+// jobsets never run concurrently for the same project.
+type Client struct {
+	*api.ClientWithResponses
+
+	// WriteMode selects how projects and jobsets are written. It must not be
+	// changed once the Client is in use.
+	WriteMode WriteMode
+
+	locks projectLocks
+	cache cache
+
+	// Whether Hydra turned out not to accept JSON, in WriteModeAuto.
+	formWrites atomic.Bool
+}
+
+// New wraps the generated client.
+func New(c *api.ClientWithResponses) *Client {
+	return &Client{ClientWithResponses: c}
+}
+
+// GetProject fetches the project with the given name.
+func (c *Client) GetProject(ctx context.Context, id string) (*api.Project, error) {
+	c.prefetchProjects(ctx)
+
+	if project, ok := c.cache.project(id); ok {
+		return project, nil
+	}
+
+	return c.FetchProject(ctx, id)
+}
+
+// FetchProject fetches the project like GetProject, but always from Hydra, for
+// when what the provider didn't see matters, such as its jobsets.
+func (c *Client) FetchProject(ctx context.Context, id string) (*api.Project, error) {
+	gen := c.cache.generation()
+
+	resp, err := c.GetProjectIdWithResponse(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.JSON200 == nil {
+		return nil, newError(resp.HTTPResponse, resp.Body)
+	}
+
+	c.cache.putProject(gen, id, resp.JSON200)
+
+	return resp.JSON200, nil
+}
+
+// CreateProject creates the project with the given name.
+func (c *Client) CreateProject(ctx context.Context, id string, body api.PutProjectIdJSONRequestBody) error {
+	defer c.locks.lock(id)()
+	defer c.cache.invalidate(id)
+
+	if c.useForms() {
+		return c.postForm(ctx, "/create-project", projectForm(id, body))
+	}
+
+	resp, err := c.PutProjectIdWithResponse(ctx, id, body)
+	if err != nil {
+		return err
+	}
+
+	if resp.JSON201 == nil {
+		if c.fallBackToForms(resp.HTTPResponse) {
+			return c.postForm(ctx, "/create-project", projectForm(id, body))
+		}
+		return newError(resp.HTTPResponse, resp.Body)
+	}
+
+	return nil
+}
+
+// UpdateProject updates (and possibly renames) the project with the given name.
+func (c *Client) UpdateProject(ctx context.Context, id string, body api.PutProjectIdJSONRequestBody) error {
+	projects := []string{id}
+	if body.Name != nil {
+		projects = append(projects, *body.Name)
+	}
+	defer c.locks.lock(projects...)()
+	defer c.cache.invalidate(projects...)
+
+	path := "/project/" + url.PathEscape(id) + "/edit"
+	if c.useForms() {
+		return c.postForm(ctx, path, projectForm(id, body))
+	}
+
+	resp, err := c.PutProjectIdWithResponse(ctx, id, body)
+	if err != nil {
+		return err
+	}
+
+	if resp.JSON200 == nil {
+		if c.fallBackToForms(resp.HTTPResponse) {
+			return c.postForm(ctx, path, projectForm(id, body))
+		}
+		return newError(resp.HTTPResponse, resp.Body)
+	}
+
+	return nil
+}
+
+// DeleteProject deletes the project with the given name, and all its jobsets.
+func (c *Client) DeleteProject(ctx context.Context, id string) error {
+	defer c.locks.lock(id)()
+	defer c.cache.invalidate(id)
+
+	resp, err := c.DeleteProjectIdWithResponse(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return newError(resp.HTTPResponse, resp.Body)
+	}
+
+	return nil
+}
+
+// GetJobset fetches the jobset of the given project.
+func (c *Client) GetJobset(ctx context.Context, project, jobset string) (*api.Jobset, error) {
+	if j, ok := c.cache.jobset(project, jobset); ok {
+		return j, nil
+	}
+
+	return c.FetchJobset(ctx, project, jobset)
+}
+
+// FetchJobset fetches the jobset of the given project like GetJobset, but
+// always from Hydra, for what changes without the provider's doing, such as
+// the status of its evaluations.
+func (c *Client) FetchJobset(ctx context.Context, project, jobset string) (*api.Jobset, error) {
+	gen := c.cache.generation()
+
+	resp, err := c.GetJobsetProjectIdJobsetIdWithResponse(ctx, project, jobset)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.JSON200 == nil {
+		return nil, newError(resp.HTTPResponse, resp.Body)
+	}
+
+	c.cache.putJobset(gen, project, jobset, resp.JSON200)
+
+	return resp.JSON200, nil
+}
+
+// JobsetExists reports whether the project has a jobset with the given name.
+// The project must exist.
+func (c *Client) JobsetExists(ctx context.Context, project, jobset string) (bool, error) {
+	if _, ok := c.cache.jobset(project, jobset); ok {
+		return true, nil
+	}
+
+	if names := c.prefetchOverview(ctx, project); names[jobset] {
+		return true, nil
+	}
+
+	// The jobset might just be hidden from the overview.
+	_, err := c.GetJobset(ctx, project, jobset)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CreateJobset creates the jobset in the given project.
+func (c *Client) CreateJobset(ctx context.Context, project, jobset string, body api.PutJobsetProjectIdJobsetIdJSONRequestBody) error {
+	defer c.locks.lock(project)()
+	defer c.cache.invalidate(project)
+
+	path := "/project/" + url.PathEscape(project) + "/create-jobset"
+	if c.useForms() {
+		return c.postForm(ctx, path, jobsetForm(jobset, body))
+	}
+
+	resp, err := c.PutJobsetProjectIdJobsetIdWithResponse(ctx, project, jobset, body)
+	if err != nil {
+		return err
+	}
+
+	if resp.JSON201 == nil {
+		if c.fallBackToForms(resp.HTTPResponse) {
+			return c.postForm(ctx, path, jobsetForm(jobset, body))
+		}
+		return newError(resp.HTTPResponse, resp.Body)
+	}
+
+	return nil
+}
+
+// UpdateJobset updates (and possibly renames or moves) the jobset of the given
+// project.
+func (c *Client) UpdateJobset(ctx context.Context, project, jobset string, body api.PutJobsetProjectIdJobsetIdJSONRequestBody) error {
+	projects := []string{project}
+	if body.Project != nil {
+		projects = append(projects, *body.Project)
+	}
+	defer c.locks.lock(projects...)()
+	defer c.cache.invalidate(projects...)
+
+	path := "/jobset/" + url.PathEscape(project) + "/" + url.PathEscape(jobset) + "/edit"
+	postForm := func() error {
+		// Hydra's jobset form has no field for the project.
+		if body.Project != nil && *body.Project != project {
+			return errors.New("jobsets can't be moved to another project without Hydra's JSON API")
+		}
+		return c.postForm(ctx, path, jobsetForm(jobset, body))
+	}
+	if c.useForms() {
+		return postForm()
+	}
+
+	resp, err := c.PutJobsetProjectIdJobsetIdWithResponse(ctx, project, jobset, body)
+	if err != nil {
+		return err
+	}
+
+	if resp.JSON200 == nil {
+		if c.fallBackToForms(resp.HTTPResponse) {
+			return postForm()
+		}
+		return newError(resp.HTTPResponse, resp.Body)
+	}
+
+	return nil
+}
+
+// DeleteJobset deletes the jobset of the given project.
+func (c *Client) DeleteJobset(ctx context.Context, project, jobset string) error {
+	defer c.locks.lock(project)()
+	defer c.cache.invalidate(project)
+
+	resp, err := c.DeleteJobsetProjectIdJobsetIdWithResponse(ctx, project, jobset)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return newError(resp.HTTPResponse, resp.Body)
+	}
+
+	return nil
+}
+
+// TriggerJobsets asks Hydra to evaluate the jobsets, given as
+// "project:jobset", as soon as possible, and returns the ones it triggered.
+func (c *Client) TriggerJobsets(ctx context.Context, jobsets []string) ([]string, error) {
+	var projects []string
+	for _, jobset := range jobsets {
+		project, _, _ := strings.Cut(jobset, ":")
+		projects = append(projects, project)
+	}
+	defer c.cache.invalidate(projects...)
+
+	param := strings.Join(jobsets, ",")
+	resp, err := c.PutApiPushWithResponse(ctx, &api.PutApiPushParams{Jobsets: &param})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.JSON200 == nil {
+		return nil, newError(resp.HTTPResponse, resp.Body)
+	}
+
+	if resp.JSON200.JobsetsTriggered == nil {
+		return []string{}, nil
+	}
+	return *resp.JSON200.JobsetsTriggered, nil
+}
+
+// LatestEvaluation returns the latest evaluation of the jobset, or nil if it
+// hasn't been evaluated yet. It's always fetched from Hydra.
+func (c *Client) LatestEvaluation(ctx context.Context, project, jobset string) (*api.JobsetEval, error) {
+	resp, err := c.GetJobsetProjectIdJobsetIdEvalsWithResponse(ctx, project, jobset)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.JSON200 == nil {
+		return nil, newError(resp.HTTPResponse, resp.Body)
+	}
+
+	var latest *api.JobsetEval
+	if resp.JSON200.Evals != nil {
+		for i, eval := range *resp.JSON200.Evals {
+			if eval.Id != nil && (latest == nil || *eval.Id > *latest.Id) {
+				latest = &(*resp.JSON200.Evals)[i]
+			}
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := api.NewClientWithResponses(server.URL + "/")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return New(c)
+}
+
+func TestClient_errors(t *testing.T) {
+	cases := []struct {
+		status  int
+		body    string
+		kind    error
+		message string
+	}{
+		{http.StatusNotFound, `{"error": "Project nixpkgs doesn't exist."}`, ErrNotFound, "Project nixpkgs doesn't exist."},
+		{http.StatusForbidden, `{"error": "This page requires you to sign in."}`, ErrForbidden, "This page requires you to sign in."},
+		{http.StatusConflict, `{"error": "Jobset already exists."}`, ErrConflict, "Jobset already exists."},
+		{http.StatusBadRequest, `{"error": "Invalid project identifier ‘Nix pkgs’."}`, ErrValidation, "Invalid project identifier ‘Nix pkgs’."},
+		{http.StatusBadGateway, "Bad Gateway\n", ErrServerError, "Bad Gateway"},
+		{http.StatusOK, "<html><body><h1>Welcome to Hydra</h1></body></html>", nil, "Welcome to Hydra"},
+	}
+
+	for _, tc := range cases {
+		t.Run(fmt.Sprint(tc.status), func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(tc.body, "{") {
+					w.Header().Set("Content-Type", "application/json")
+				}
+				w.WriteHeader(tc.status)
+				fmt.Fprint(w, tc.body)
+			})
+
+			_, err := c.GetProject(context.Background(), "nixpkgs")
+
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an *Error, got %v", err)
+			}
+			if apiErr.Kind != tc.kind || (tc.kind != nil && !errors.Is(err, tc.kind)) {
+				t.Errorf("expected kind %v, got %v", tc.kind, apiErr.Kind)
+			}
+			if apiErr.Message != tc.message {
+				t.Errorf("expected message %q, got %q", tc.message, apiErr.Message)
+			}
+		})
+	}
+}
+
+func TestClient_createProject(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/project/nixpkgs" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"name": "nixpkgs", "redirect": "/project/nixpkgs"}`)
+	})
+
+	if err := c.CreateProject(context.Background(), "nixpkgs", api.PutProjectIdJSONRequestBody{}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestClient_triggerJobsets(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/api/push" || r.URL.Query().Get("jobsets") != "nixpkgs:trunk,nixos:release" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"jobsetsTriggered": ["nixpkgs:trunk"]}`)
+	})
+
+	triggered, err := c.TriggerJobsets(context.Background(), []string{"nixpkgs:trunk", "nixos:release"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(triggered) != 1 || triggered[0] != "nixpkgs:trunk" {
+		t.Errorf("unexpected triggered jobsets %v", triggered)
+	}
+}
+
+func TestClient_latestEvaluation(t *testing.T) {
+	evals := `{"first": "?page=1", "last": "?page=1", "evals": [{"id": 3, "timestamp": 1700000300, "hasnewbuilds": true}, {"id": 7, "timestamp": 1700000700}, {"id": 5}]}`
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/jobset/nixpkgs/trunk/evals":
+			fmt.Fprint(w, evals)
+		case "/jobset/nixpkgs/new/evals":
+			fmt.Fprint(w, `{"first": "?page=1", "last": "?page=1", "evals": []}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": "Jobset doesn't exist."}`)
+		}
+	})
+	ctx := context.Background()
+
+	eval, err := c.LatestEvaluation(ctx, "nixpkgs", "trunk")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if eval == nil || *eval.Id != 7 {
+		t.Errorf("expected evaluation 7, got %+v", eval)
+	}
+
+	if eval, err := c.LatestEvaluation(ctx, "nixpkgs", "new"); err != nil || eval != nil {
+		t.Errorf("expected no evaluation, got %+v, %v", eval, err)
+	}
+
+	if _, err := c.LatestEvaluation(ctx, "nixpkgs", "gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+// The longest message DecodeErrorBody returns, so that a huge page doesn't
+// drown the rest of a diagnostic.
+const maxMessageLength = 1000
+
+// DecodeErrorBody extracts a readable message from the body of an error
+// response. It understands the `{"error": "..."}` objects of Hydra's API,
+// Hydra's HTML error page, and the HTML or plain-text pages that proxies answer
+// with, and returns "" for an empty body.
+func DecodeErrorBody(contentType string, body []byte) string {
+	msg, _ := decodeErrorBody(contentType, body)
+	return msg
+}
+
+// IsHydraError reports whether the body of an error response carries an error
+// reported by Hydra itself, in its API's JSON or on its error page, rather
+// than e.g. the page of a proxy in front of it.
+func IsHydraError(contentType string, body []byte) bool {
+	_, fromHydra := decodeErrorBody(contentType, body)
+	return fromHydra
+}
+
+func decodeErrorBody(contentType string, body []byte) (string, bool) {
+	body = bytes.TrimSpace(body)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var msg string
+	var fromHydra bool
+	switch {
+	case strings.Contains(mediaType, "json") || bytes.HasPrefix(body, []byte("{")):
+		msg, fromHydra = decodeJSONError(body)
+	case mediaType == "text/html" || bytes.HasPrefix(body, []byte("<")):
+		msg, fromHydra = decodeHTMLError(body)
+	}
+
+	if msg == "" {
+		msg = string(body)
+	}
+
+	return clean(msg), fromHydra
+}
+
+func decodeJSONError(body []byte) (string, bool) {
+	var hydraErr api.Error
+	if json.Unmarshal(body, &hydraErr) != nil || hydraErr.Error == nil {
+		return "", false
+	}
+
+	return *hydraErr.Error, true
+}
+
+// decodeHTMLError returns the errors listed on Hydra's error page, which are
+// rendered as `<pre class="alert alert-error">` (or `alert-danger`) elements.
+// For other pages, e.g. the "502 Bad Gateway" page of a proxy, the title or
+// first heading is the best summary there is. It also reports whether the
+// page is Hydra's.
+func decodeHTMLError(body []byte) (string, bool) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return "", false
+	}
+
+	var messages []string
+	var title, heading, text string
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch {
+			case isErrorElement(n):
+				messages = append(messages, TextOf(n))
+				return
+			case n.Data == "title" && title == "":
+				title = TextOf(n)
+			case n.Data == "h1" && heading == "":
+				heading = TextOf(n)
+			case n.Data == "body":
+				text = TextOf(n)
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	switch {
+	case len(messages) > 0:
+		return strings.Join(messages, "\n"), true
+	case heading != "":
+		return heading, false
+	case title != "":
+		return title, false
+	default:
+		return text, false
+	}
+}
+
+func isErrorElement(n *html.Node) bool {
+	if n.Data != "pre" && n.Data != "div" {
+		return false
+	}
+
+	for _, attr := range n.Attr {
+		if attr.Key != "class" {
+			continue
+		}
+		for _, class := range strings.Fields(attr.Val) {
+			switch class {
+			case "alert-error", "alert-danger", "error":
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// TextOf returns the text of the node and its descendants, without that of
+// scripts and styles.
+func TextOf(n *html.Node) string {
+	var b strings.Builder
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style"):
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	return b.String()
+}
+
+// clean collapses the whitespace within each line, drops empty lines, and
+// truncates overly long messages.
+func clean(msg string) string {
+	var lines []string
+	for _, line := range strings.Split(msg, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	msg = strings.Join(lines, "\n")
+	if len(msg) > maxMessageLength {
+		msg = strings.ToValidUTF8(msg[:maxMessageLength], "") + "..."
+	}
+
+	return msg
+}
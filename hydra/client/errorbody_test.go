@@ -0,0 +1,79 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeErrorBody(t *testing.T) {
+	cases := map[string]struct {
+		contentType string
+		body        string
+		expected    string
+		fromHydra   bool
+	}{
+		"json": {
+			contentType: "application/json",
+			body:        `{"error":"Invalid jobset identifier ‘foo bar’."}`,
+			expected:    "Invalid jobset identifier ‘foo bar’.",
+			fromHydra:   true,
+		},
+		"json without error": {
+			contentType: "application/json",
+			body:        `{"message":"something else"}`,
+			expected:    `{"message":"something else"}`,
+		},
+		"hydra error page": {
+			contentType: "text/html; charset=utf-8",
+			body: `<!DOCTYPE html>
+<html>
+  <head><title>Error</title><script>var x = 1;</script></head>
+  <body>
+    <div class="navbar">Hydra</div>
+    <p>I'm very sorry, but an error occurred:</p>
+    <pre class="alert alert-error">Invalid input type ‘svn’.</pre>
+  </body>
+</html>`,
+			expected:  "Invalid input type ‘svn’.",
+			fromHydra: true,
+		},
+		"proxy error page": {
+			contentType: "text/html",
+			body: `<html>
+<head><title>502 Bad Gateway</title></head>
+<body>
+<center><h1>502 Bad Gateway</h1></center>
+<hr><center>nginx</center>
+</body>
+</html>`,
+			expected: "502 Bad Gateway",
+		},
+		"plain text": {
+			contentType: "text/plain",
+			body:        "  upstream connect error or disconnect/reset before headers.\n\n  reset reason: overflow\n",
+			expected:    "upstream connect error or disconnect/reset before headers.\nreset reason: overflow",
+		},
+		"empty": {
+			body:     "",
+			expected: "",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if msg := DecodeErrorBody(tc.contentType, []byte(tc.body)); msg != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, msg)
+			}
+			if fromHydra := IsHydraError(tc.contentType, []byte(tc.body)); fromHydra != tc.fromHydra {
+				t.Errorf("expected the error to be from Hydra: %t, got %t", tc.fromHydra, fromHydra)
+			}
+		})
+	}
+}
+
+func TestDecodeErrorBody_truncates(t *testing.T) {
+	msg := DecodeErrorBody("text/plain", []byte(strings.Repeat("x", 2*maxMessageLength)))
+	if len(msg) != maxMessageLength+len("...") {
+		t.Errorf("expected the message to be truncated, got %d characters", len(msg))
+	}
+}
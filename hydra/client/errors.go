@@ -0,0 +1,86 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// The kinds of errors Hydra can respond with. Use errors.Is to check whether an
+// error returned by the Client is of a certain kind.
+var (
+	// ErrNotFound means the project, jobset, etc. doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden means the user isn't logged in, or lacks the necessary role.
+	ErrForbidden = errors.New("forbidden")
+	// ErrConflict means the request conflicts with the current state in Hydra.
+	ErrConflict = errors.New("conflict")
+	// ErrValidation means Hydra rejected the request's input.
+	ErrValidation = errors.New("validation failed")
+	// ErrServerError means Hydra (or a proxy in front of it) failed to process
+	// the request.
+	ErrServerError = errors.New("server error")
+)
+
+// Error is returned when Hydra doesn't answer a request with the expected
+// response.
+type Error struct {
+	// Kind is one of the Err* values above, or nil for responses that don't fit
+	// any of them (e.g. a 200 without the expected body).
+	Kind error
+
+	StatusCode int
+	Status     string
+
+	// Message is the error message from Hydra (or the proxy in front of it),
+	// as extracted by DecodeErrorBody.
+	Message string
+	// FromHydra tells whether Message was reported by Hydra itself, see
+	// IsHydraError.
+	FromHydra bool
+
+	// Body is the raw response body.
+	Body []byte
+}
+
+func (e *Error) Error() string {
+	if e.Kind == nil {
+		return fmt.Sprintf("unexpected response from Hydra, got %s: %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("%s, got %s: %s", e.Kind, e.Status, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// newError builds the Error for an unexpected response.
+func newError(resp *http.Response, body []byte) *Error {
+	message, fromHydra := decodeErrorBody(resp.Header.Get("Content-Type"), body)
+
+	return &Error{
+		Kind:       kindOf(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Message:    message,
+		FromHydra:  fromHydra,
+		Body:       body,
+	}
+}
+
+func kindOf(status int) error {
+	switch {
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrForbidden
+	case status == http.StatusConflict:
+		return ErrConflict
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return ErrValidation
+	case status >= 500:
+		return ErrServerError
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+// WriteMode selects how the Client sends changes to projects and jobsets.
+type WriteMode int
+
+const (
+	// WriteModeAuto sends JSON, until Hydra turns out not to accept it, and
+	// then uses the forms of the web interface from then on.
+	WriteModeAuto WriteMode = iota
+	// WriteModeJSON always sends JSON to `PUT /project/{id}` and
+	// `PUT /jobset/{project}/{jobset}`.
+	WriteModeJSON
+	// WriteModeForm always posts the forms of Hydra's web interface, for
+	// Hydras that don't accept JSON.
+	WriteModeForm
+)
+
+// useForms reports whether to post forms instead of sending JSON.
+func (c *Client) useForms() bool {
+	return c.WriteMode == WriteModeForm || (c.WriteMode == WriteModeAuto && c.formWrites.Load())
+}
+
+// JSONRejected reports whether Hydra turned out not to accept JSON writes, and
+// the Client posts forms instead.
+func (c *Client) JSONRejected() bool {
+	return c.formWrites.Load()
+}
+
+// fallBackToForms reports whether the JSON request should be sent as a form
+// instead, because Hydra doesn't accept JSON, and remembers that it doesn't.
+func (c *Client) fallBackToForms(resp *http.Response) bool {
+	if c.WriteMode != WriteModeAuto || !jsonUnsupported(resp) {
+		return false
+	}
+
+	c.formWrites.Store(true)
+	return true
+}
+
+// jsonUnsupported reports whether the response to a JSON request means that
+// Hydra doesn't accept JSON there: it rejects the method or the content type,
+// or it answers successfully with a page instead of JSON. Error pages aren't
+// enough, since a "not found" or "bad request" page may just as well come
+// from a proxy in front of Hydra, or from a Hydra that does accept JSON but
+// failed before it could tell.
+func jsonUnsupported(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusUnsupportedMediaType, http.StatusNotImplemented:
+		return true
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return !strings.Contains(mediaType, "json")
+}
+
+// postForm posts a form of Hydra's web interface. path is relative to the root
+// of Hydra, with its segments escaped. Hydra redirects to the changed object
+// when it's done.
+func (c *Client) postForm(ctx context.Context, path string, form url.Values) error {
+	raw, ok := c.ClientInterface.(*api.Client)
+	if !ok {
+		return errors.New("the client can't post forms")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, raw.Server+strings.TrimPrefix(path, "/"), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+
+	for _, editor := range raw.RequestEditors {
+		if err := editor(ctx, req); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := raw.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return newError(resp, body)
+	}
+
+	return nil
+}
+
+func setFlag(form url.Values, key string, value *bool) {
+	if value != nil && *value {
+		form.Set(key, "on")
+	}
+}
+
+func setString(form url.Values, key string, value *string) {
+	if value != nil {
+		form.Set(key, *value)
+	}
+}
+
+func setInt(form url.Values, key string, value *int) {
+	if value != nil {
+		form.Set(key, strconv.Itoa(*value))
+	}
+}
+
+// projectForm encodes a project as the fields of Hydra's project form. id is
+// the name of the project unless the body renames it.
+func projectForm(id string, body api.PutProjectIdJSONRequestBody) url.Values {
+	form := url.Values{}
+
+	form.Set("name", id)
+	setString(form, "name", body.Name)
+	setString(form, "displayname", body.Displayname)
+	setString(form, "description", body.Description)
+	setString(form, "homepage", body.Homepage)
+	setString(form, "owner", body.Owner)
+	setFlag(form, "enabled", body.Enabled)
+	setFlag(form, "visible", body.Visible)
+	setFlag(form, "enable_dynamic_run_command", body.EnableDynamicRunCommand)
+
+	if decl := body.Declarative; decl != nil {
+		setString(form, "declfile", decl.File)
+		setString(form, "decltype", decl.Type)
+		setString(form, "declvalue", decl.Value)
+	}
+
+	return form
+}
+
+// jobsetForm encodes a jobset as the fields of Hydra's jobset form, in which
+// each input has a set of fields named after it. jobset is the name of the
+// jobset unless the body renames it.
+func jobsetForm(jobset string, body api.PutJobsetProjectIdJobsetIdJSONRequestBody) url.Values {
+	form := url.Values{}
+
+	form.Set("name", jobset)
+	setString(form, "name", body.Name)
+	setInt(form, "enabled", body.Enabled)
+	setInt(form, "type", body.Type)
+	setFlag(form, "visible", body.Visible)
+	setString(form, "description", body.Description)
+	setString(form, "nixexprinput", body.Nixexprinput)
+	setString(form, "nixexprpath", body.Nixexprpath)
+	setString(form, "flake", body.Flake)
+	setInt(form, "checkinterval", body.Checkinterval)
+	setInt(form, "schedulingshares", body.Schedulingshares)
+	setInt(form, "keepnr", body.Keepnr)
+	setFlag(form, "enableemail", body.Enableemail)
+	setString(form, "emailoverride", body.Emailoverride)
+	setFlag(form, "enable_dynamic_run_command", body.EnableDynamicRunCommand)
+
+	if body.Inputs != nil {
+		names := make([]string, 0, len(*body.Inputs))
+		for name := range *body.Inputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			input := (*body.Inputs)[name]
+			prefix := "input-" + name + "-"
+
+			form.Set(prefix+"name", name)
+			setString(form, prefix+"type", input.Type)
+			setString(form, prefix+"value", input.Value)
+			setFlag(form, prefix+"emailresponsible", input.Emailresponsible)
+		}
+	}
+
+	return form
+}
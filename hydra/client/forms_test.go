@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+func strPtr(s string) *string { return &s }
+func intPtr(i int) *int       { return &i }
+func boolPtr(b bool) *bool    { return &b }
+
+func TestProjectForm(t *testing.T) {
+	form := projectForm("nixpkgs", api.PutProjectIdJSONRequestBody{
+		Name:        strPtr("nixos"),
+		Displayname: strPtr("NixOS"),
+		Description: strPtr(""),
+		Enabled:     boolPtr(true),
+		Declarative: &api.DeclarativeInput{
+			File:  strPtr("spec.json"),
+			Type:  strPtr("git"),
+			Value: strPtr("https://github.com/NixOS/nixpkgs"),
+		},
+	})
+
+	want := url.Values{
+		"name":        {"nixos"},
+		"displayname": {"NixOS"},
+		"description": {""},
+		"enabled":     {"on"},
+		"declfile":    {"spec.json"},
+		"decltype":    {"git"},
+		"declvalue":   {"https://github.com/NixOS/nixpkgs"},
+	}
+	if !reflect.DeepEqual(form, want) {
+		t.Errorf("expected %v, got %v", want, form)
+	}
+}
+
+func TestJobsetForm(t *testing.T) {
+	form := jobsetForm("trunk", api.PutJobsetProjectIdJobsetIdJSONRequestBody{
+		Enabled:       intPtr(2),
+		Type:          intPtr(0),
+		Visible:       boolPtr(true),
+		Enableemail:   boolPtr(false),
+		Nixexprinput:  strPtr("nixpkgs"),
+		Nixexprpath:   strPtr("release.nix"),
+		Checkinterval: intPtr(60),
+		Inputs: &map[string]api.JobsetInput{
+			"nixpkgs": {
+				Name:             strPtr("nixpkgs"),
+				Type:             strPtr("git"),
+				Value:            strPtr("https://github.com/NixOS/nixpkgs"),
+				Emailresponsible: boolPtr(true),
+			},
+		},
+	})
+
+	want := url.Values{
+		"name":                           {"trunk"},
+		"enabled":                        {"2"},
+		"type":                           {"0"},
+		"visible":                        {"on"},
+		"nixexprinput":                   {"nixpkgs"},
+		"nixexprpath":                    {"release.nix"},
+		"checkinterval":                  {"60"},
+		"input-nixpkgs-name":             {"nixpkgs"},
+		"input-nixpkgs-type":             {"git"},
+		"input-nixpkgs-value":            {"https://github.com/NixOS/nixpkgs"},
+		"input-nixpkgs-emailresponsible": {"on"},
+	}
+	if !reflect.DeepEqual(form, want) {
+		t.Errorf("expected %v, got %v", want, form)
+	}
+}
+
+// formHydra is an old Hydra, which doesn't allow JSON writes and records the
+// forms posted to it.
+type formHydra struct {
+	mu       sync.Mutex
+	requests []string
+	forms    map[string]url.Values
+}
+
+func (h *formHydra) handle(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.requests = append(h.requests, r.Method+" "+r.URL.Path)
+
+	switch r.Method {
+	case http.MethodPut:
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		io.WriteString(w, "<html><body><h1>Method not allowed</h1></body></html>")
+	case http.MethodPost:
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if h.forms == nil {
+			h.forms = make(map[string]url.Values)
+		}
+		h.forms[r.URL.Path] = r.PostForm
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><body><h1>Project nixpkgs</h1></body></html>")
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestClient_autoFallsBackToForms(t *testing.T) {
+	hydra := &formHydra{}
+	c := newTestClient(t, hydra.handle)
+	ctx := context.Background()
+
+	if err := c.CreateProject(ctx, "nixpkgs", api.PutProjectIdJSONRequestBody{Displayname: strPtr("Nixpkgs")}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := c.UpdateJobset(ctx, "nixpkgs", "trunk", api.PutJobsetProjectIdJobsetIdJSONRequestBody{Enabled: intPtr(1)}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Once Hydra turned out not to accept JSON, it isn't sent any more.
+	want := []string{
+		"PUT /project/nixpkgs",
+		"POST /create-project",
+		"POST /jobset/nixpkgs/trunk/edit",
+	}
+	if !reflect.DeepEqual(hydra.requests, want) {
+		t.Errorf("expected requests %v, got %v", want, hydra.requests)
+	}
+
+	if form := hydra.forms["/create-project"]; form.Get("name") != "nixpkgs" || form.Get("displayname") != "Nixpkgs" {
+		t.Errorf("unexpected project form %v", form)
+	}
+	if form := hydra.forms["/jobset/nixpkgs/trunk/edit"]; form.Get("name") != "trunk" || form.Get("enabled") != "1" {
+		t.Errorf("unexpected jobset form %v", form)
+	}
+}
+
+func TestClient_autoKeepsJSONOnErrorPages(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+
+		// A proxy in front of Hydra that doesn't know the path.
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "<html><body><h1>404 Not Found</h1></body></html>")
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		err := c.UpdateProject(ctx, "nixpkgs", api.PutProjectIdJSONRequestBody{})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected the error page to be reported, got %v", err)
+		}
+	}
+
+	want := []string{
+		"PUT /project/nixpkgs",
+		"PUT /project/nixpkgs",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("expected requests %v, got %v", want, requests)
+	}
+	if c.JSONRejected() {
+		t.Errorf("expected an error page not to turn off JSON writes")
+	}
+}
+
+func TestClient_writeModes(t *testing.T) {
+	cases := map[WriteMode][]string{
+		WriteModeJSON: {"PUT /project/nixpkgs"},
+		WriteModeForm: {"POST /project/nixpkgs/edit"},
+	}
+
+	for mode, want := range cases {
+		hydra := &formHydra{}
+		c := newTestClient(t, hydra.handle)
+		c.WriteMode = mode
+
+		err := c.UpdateProject(context.Background(), "nixpkgs", api.PutProjectIdJSONRequestBody{})
+		if (err != nil) != (mode == WriteModeJSON) {
+			t.Errorf("mode %d: unexpected error %v", mode, err)
+		}
+		if !reflect.DeepEqual(hydra.requests, want) {
+			t.Errorf("mode %d: expected requests %v, got %v", mode, want, hydra.requests)
+		}
+	}
+}
+
+func TestClient_formErrors(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "<html><body><p>Invalid jobset identifier ‘foo bar’.</p></body></html>")
+	})
+	c.WriteMode = WriteModeForm
+
+	err := c.CreateJobset(context.Background(), "nixpkgs", "foo bar", api.PutJobsetProjectIdJobsetIdJSONRequestBody{})
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+
+	err = c.UpdateJobset(context.Background(), "nixpkgs", "trunk", api.PutJobsetProjectIdJobsetIdJSONRequestBody{Project: strPtr("nixos")})
+	if err == nil {
+		t.Errorf("expected moving a jobset to fail")
+	}
+}
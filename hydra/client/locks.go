@@ -0,0 +1,55 @@
+package client
+
+import (
+	"sort"
+	"sync"
+)
+
+// projectLocks serializes the changes made to the same project (and its
+// jobsets), as Hydra doesn't cope well with concurrent writes to a project.
+type projectLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// lock locks all of the projects, and returns the function that unlocks them
+// again. The projects are always locked in the same order, so that concurrent
+// calls involving several projects (e.g. moving a jobset) can't deadlock.
+func (l *projectLocks) lock(projects ...string) func() {
+	projects = append([]string(nil), projects...)
+	sort.Strings(projects)
+
+	var held []*sync.Mutex
+	for i, project := range projects {
+		if i > 0 && project == projects[i-1] {
+			continue
+		}
+
+		m := l.get(project)
+		m.Lock()
+		held = append(held, m)
+	}
+
+	return func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].Unlock()
+		}
+	}
+}
+
+func (l *projectLocks) get(project string) *sync.Mutex {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locks == nil {
+		l.locks = make(map[string]*sync.Mutex)
+	}
+
+	m, ok := l.locks[project]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[project] = m
+	}
+
+	return m
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+func TestClient_serializesWritesPerProject(t *testing.T) {
+	var inFlight, overlaps int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&inFlight, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"redirect": "/jobset/nixpkgs/trunk"}`)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := c.UpdateJobset(context.Background(), "nixpkgs", fmt.Sprintf("jobset-%d", i), api.PutJobsetProjectIdJobsetIdJSONRequestBody{})
+			if err != nil {
+				t.Errorf("err: %s", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&overlaps); n != 0 {
+		t.Errorf("expected writes to the same project to be serialized, got %d overlapping requests", n)
+	}
+}
+
+func TestProjectLocks_multipleProjects(t *testing.T) {
+	var locks projectLocks
+
+	// Locking the same projects in opposite orders (and the same project twice)
+	// must neither deadlock nor block forever.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				locks.lock("a", "b")()
+			}()
+			go func() {
+				defer wg.Done()
+				locks.lock("b", "a", "a")()
+			}()
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlocked")
+	}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+// GetPage fetches a page of Hydra's web interface, for what the API doesn't
+// tell, and returns its HTML. path is relative to the root of Hydra, with its
+// segments escaped.
+func (c *Client) GetPage(ctx context.Context, path string) ([]byte, error) {
+	raw, ok := c.ClientInterface.(*api.Client)
+	if !ok {
+		return nil, errors.New("the client can't fetch pages")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, raw.Server+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, editor := range raw.RequestEditors {
+		if err := editor(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Del("Content-Type")
+
+	resp, err := raw.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return nil, newError(resp, body)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+// GetUser fetches the user with the given name. Only admins can see other
+// users.
+func (c *Client) GetUser(ctx context.Context, username string) (*api.User, error) {
+	resp, err := c.GetUserUsernameWithResponse(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.JSON200 == nil {
+		return nil, newError(resp.HTTPResponse, resp.Body)
+	}
+
+	return resp.JSON200, nil
+}
+
+// CreateUser creates the user with the given name. Hydra only lets admins
+// create users, with the form of its web interface, which takes the same
+// fields as updating a user, plus the name.
+func (c *Client) CreateUser(ctx context.Context, username string, body api.PutRegisterJSONRequestBody) error {
+	body.Username = &username
+
+	resp, err := c.PutRegisterWithResponse(ctx, body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != http.StatusNoContent {
+		return newError(resp.HTTPResponse, resp.Body)
+	}
+
+	return nil
+}
+
+// UpdateUser updates the user with the given name. The password is left
+// unchanged unless the body has one.
+func (c *Client) UpdateUser(ctx context.Context, username string, body api.PutUserUsernameJSONRequestBody) error {
+	resp, err := c.PutUserUsernameWithResponse(ctx, username, body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != http.StatusNoContent {
+		return newError(resp.HTTPResponse, resp.Body)
+	}
+
+	return nil
+}
+
+// DeleteUser deletes the user with the given name.
+func (c *Client) DeleteUser(ctx context.Context, username string) error {
+	resp, err := c.DeleteUserUsernameWithResponse(ctx, username)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != http.StatusNoContent {
+		return newError(resp.HTTPResponse, resp.Body)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+func TestClient_createUser(t *testing.T) {
+	var got api.UserInput
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/register" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Hydra answers with no content, like when updating a user.
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := c.CreateUser(context.Background(), "alice", api.PutRegisterJSONRequestBody{
+		Fullname:  strPtr("Alice"),
+		Password:  strPtr("hunter22"),
+		Password2: strPtr("hunter22"),
+		Roles:     &[]string{"admin"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := api.UserInput{
+		Username:  strPtr("alice"),
+		Fullname:  strPtr("Alice"),
+		Password:  strPtr("hunter22"),
+		Password2: strPtr("hunter22"),
+		Roles:     &[]string{"admin"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the user %+v to be sent, got %+v", want, got)
+	}
+}
+
+func TestClient_createUserErrors(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"error": "Your user name is already taken."}`)
+	})
+
+	err := c.CreateUser(context.Background(), "alice", api.PutRegisterJSONRequestBody{})
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || !errors.Is(err, ErrServerError) {
+		t.Fatalf("expected a server error, got %v", err)
+	}
+	if apiErr.Message != "Your user name is already taken." || !apiErr.FromHydra {
+		t.Errorf("expected Hydra's message, got %q", apiErr.Message)
+	}
+}
+
+func TestClient_updateAndDeleteUser(t *testing.T) {
+	var requests []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ctx := context.Background()
+
+	if err := c.UpdateUser(ctx, "alice", api.PutUserUsernameJSONRequestBody{Fullname: strPtr("Alice")}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := c.DeleteUser(ctx, "alice"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := []string{"PUT /user/alice", "DELETE /user/alice"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("expected requests %v, got %v", want, requests)
+	}
+}
@@ -0,0 +1,326 @@
+package hydra
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// How long a credential helper may take before it is killed.
+const credentialHelperTimeout = 30 * time.Second
+
+// credentialSources describes everywhere the provider may find the credentials
+// of the Hydra user. They are consulted in the following order, and the first
+// one that provides a password wins:
+//
+//  1. `password` (or HYDRA_PASSWORD)
+//  2. `password_file` (or HYDRA_PASSWORD_FILE)
+//  3. `credential_helper` (or HYDRA_CREDENTIAL_HELPER)
+//  4. the netrc file (`netrc_file`, NETRC or ~/.netrc), keyed by the host
+//
+// A `username` (or HYDRA_USERNAME) always takes precedence over a username
+// provided by the credential helper or the netrc file.
+type credentialSources struct {
+	Host             string
+	Username         string
+	Password         string
+	PasswordFile     string
+	CredentialHelper string
+	NetrcFile        string
+}
+
+// errNoCredentials is returned when none of the credential sources provided a
+// password.
+var errNoCredentials = errors.New("no password was found; set one of `password`, `password_file` or " +
+	"`credential_helper`, or add an entry for the host to your netrc file")
+
+// credentials are the username and password to log in with, together with a
+// description of where they came from.
+type credentials struct {
+	Username string
+	Password string
+	Source   string
+}
+
+func (s *credentialSources) resolve(ctx context.Context) (*credentials, error) {
+	if s.Password != "" {
+		return s.withUsername("password", "", s.Password)
+	}
+
+	if s.PasswordFile != "" {
+		source := fmt.Sprintf("password_file (%s)", s.PasswordFile)
+
+		password, err := readPasswordFile(s.PasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+
+		return s.withUsername(source, "", password)
+	}
+
+	if s.CredentialHelper != "" {
+		source := fmt.Sprintf("credential_helper (%s)", s.CredentialHelper)
+
+		username, password, err := runCredentialHelper(ctx, s.CredentialHelper, s.Host, s.Username)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+
+		return s.withUsername(source, username, password)
+	}
+
+	netrcFile, err := s.netrcPath()
+	if err != nil {
+		return nil, fmt.Errorf("netrc: %w", err)
+	}
+
+	source := fmt.Sprintf("netrc (%s)", netrcFile)
+
+	username, password, err := lookupNetrc(netrcFile, s.Host, s.Username)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	if password != "" {
+		return s.withUsername(source, username, password)
+	}
+
+	return nil, errNoCredentials
+}
+
+// withUsername finishes the credentials, preferring the configured username
+// over one provided by the source.
+func (s *credentialSources) withUsername(source, username, password string) (*credentials, error) {
+	if s.Username != "" {
+		username = s.Username
+	}
+
+	if username == "" {
+		return nil, fmt.Errorf("%s: no username was configured or provided by the source", source)
+	}
+
+	return &credentials{
+		Username: username,
+		Password: password,
+		Source:   source,
+	}, nil
+}
+
+func (s *credentialSources) netrcPath() (string, error) {
+	if s.NetrcFile != "" {
+		return s.NetrcFile, nil
+	}
+
+	if v := os.Getenv("NETRC"); v != "" {
+		return v, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := ".netrc"
+	if runtime.GOOS == "windows" {
+		name = "_netrc"
+	}
+
+	return filepath.Join(home, name), nil
+}
+
+func readPasswordFile(path string) (string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	password := strings.TrimRight(string(contents), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("file is empty")
+	}
+
+	return password, nil
+}
+
+// runCredentialHelper executes the credential helper through the shell and
+// decodes the `{"username": ..., "password": ...}` object it prints on stdout.
+// The helper is told which host (and username, if known) the credentials are
+// for through the HYDRA_CREDENTIAL_HOST and HYDRA_CREDENTIAL_USERNAME
+// environment variables.
+func runCredentialHelper(ctx context.Context, command, host, username string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, credentialHelperTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		"HYDRA_CREDENTIAL_HOST="+host,
+		"HYDRA_CREDENTIAL_USERNAME="+username,
+	)
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", "", err
+	}
+
+	var out struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return "", "", fmt.Errorf("expected a JSON object with `username` and `password` on stdout: %w", err)
+	}
+
+	if out.Password == "" {
+		return "", "", fmt.Errorf("no password was printed")
+	}
+
+	return out.Username, out.Password, nil
+}
+
+// lookupNetrc finds the login and password of the netrc entry whose machine
+// matches the host of the Hydra URL, falling back to the `default` entry. If a
+// username is given, only entries without a login or with that same login
+// match. A missing netrc file is not an error.
+func lookupNetrc(path, host, username string) (string, string, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", "", err
+	}
+
+	machine := u.Hostname()
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	entries, err := parseNetrc(f)
+	if err != nil {
+		return "", "", err
+	}
+
+	var fallback *netrcEntry
+	for i := range entries {
+		e := &entries[i]
+
+		if username != "" && e.Login != "" && e.Login != username {
+			continue
+		}
+
+		if e.Machine == machine {
+			return e.Login, e.Password, nil
+		}
+
+		if e.Default && fallback == nil {
+			fallback = e
+		}
+	}
+
+	if fallback != nil {
+		return fallback.Login, fallback.Password, nil
+	}
+
+	return "", "", nil
+}
+
+type netrcEntry struct {
+	Machine  string
+	Default  bool
+	Login    string
+	Password string
+}
+
+func parseNetrc(r io.Reader) ([]netrcEntry, error) {
+	var entries []netrcEntry
+	var cur *netrcEntry
+
+	scanner := bufio.NewScanner(r)
+	inMacro := false
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Macro definitions run until the next empty line.
+		if inMacro {
+			if strings.TrimSpace(line) == "" {
+				inMacro = false
+			}
+			continue
+		}
+
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			next := func() (string, error) {
+				i++
+				if i >= len(fields) {
+					return "", fmt.Errorf("missing value for %q", fields[i-1])
+				}
+				return fields[i], nil
+			}
+
+			switch fields[i] {
+			case "machine":
+				name, err := next()
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, netrcEntry{Machine: name})
+				cur = &entries[len(entries)-1]
+			case "default":
+				entries = append(entries, netrcEntry{Default: true})
+				cur = &entries[len(entries)-1]
+			case "login", "password", "account":
+				key := fields[i]
+				value, err := next()
+				if err != nil {
+					return nil, err
+				}
+				if cur == nil {
+					return nil, fmt.Errorf("%q appears before any machine", key)
+				}
+				switch key {
+				case "login":
+					cur.Login = value
+				case "password":
+					cur.Password = value
+				}
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,130 @@
+package hydra
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, name, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return path
+}
+
+func TestCredentialSources_precedence(t *testing.T) {
+	ctx := context.Background()
+	passwordFile := writeTestFile(t, "password", "from-file\n")
+	netrc := writeTestFile(t, "netrc", "machine hydra.example.com login bob password from-netrc\n")
+
+	sources := credentialSources{
+		Host:             "https://hydra.example.com",
+		Username:         "alice",
+		Password:         "literal",
+		PasswordFile:     passwordFile,
+		CredentialHelper: `echo '{"username": "carol", "password": "from-helper"}'`,
+		NetrcFile:        netrc,
+	}
+
+	expect := func(password, source string) {
+		t.Helper()
+		creds, err := sources.resolve(ctx)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if creds.Password != password || !strings.HasPrefix(creds.Source, source) {
+			t.Errorf("expected %q from %s, got %q from %s", password, source, creds.Password, creds.Source)
+		}
+		if creds.Username != "alice" {
+			t.Errorf("expected the configured username to win, got %q", creds.Username)
+		}
+	}
+
+	expect("literal", "password")
+
+	sources.Password = ""
+	expect("from-file", "password_file")
+
+	sources.PasswordFile = ""
+	expect("from-helper", "credential_helper")
+
+	// The netrc entry is for a different login than the configured username.
+	sources.CredentialHelper = ""
+	if _, err := sources.resolve(ctx); err == nil {
+		t.Errorf("expected a netrc entry for another login to be ignored")
+	}
+
+	sources.Username = ""
+	creds, err := sources.resolve(ctx)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if creds.Username != "bob" || creds.Password != "from-netrc" {
+		t.Errorf("unexpected credentials from netrc: %+v", creds)
+	}
+}
+
+func TestCredentialSources_errors(t *testing.T) {
+	ctx := context.Background()
+
+	sources := credentialSources{
+		Host:         "https://hydra.example.com",
+		Username:     "alice",
+		PasswordFile: filepath.Join(t.TempDir(), "missing"),
+	}
+	if _, err := sources.resolve(ctx); err == nil || !strings.Contains(err.Error(), "password_file") {
+		t.Errorf("expected an error mentioning password_file, got %v", err)
+	}
+
+	sources = credentialSources{
+		Host:             "https://hydra.example.com",
+		Username:         "alice",
+		CredentialHelper: "echo not json",
+	}
+	if _, err := sources.resolve(ctx); err == nil || !strings.Contains(err.Error(), "credential_helper") {
+		t.Errorf("expected an error mentioning credential_helper, got %v", err)
+	}
+
+	sources = credentialSources{
+		Host:      "https://hydra.example.com",
+		Username:  "alice",
+		NetrcFile: filepath.Join(t.TempDir(), "missing"),
+	}
+	if _, err := sources.resolve(ctx); err == nil || !strings.Contains(err.Error(), "no password was found") {
+		t.Errorf("expected an error about a missing password, got %v", err)
+	}
+}
+
+func TestParseNetrc(t *testing.T) {
+	netrc := `# comment
+machine other.example.com
+  login someone
+  password secret
+macdef init
+  cd /pub
+
+machine hydra.example.com login alice password hunter2
+default login anonymous password guest
+`
+
+	entries, err := parseNetrc(strings.NewReader(netrc))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(entries), entries)
+	}
+
+	if e := entries[1]; e.Machine != "hydra.example.com" || e.Login != "alice" || e.Password != "hunter2" {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	if e := entries[2]; !e.Default || e.Login != "anonymous" {
+		t.Errorf("unexpected default entry: %+v", e)
+	}
+}
@@ -0,0 +1,84 @@
+package hydra
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceHydraInstance() *schema.Resource {
+	return &schema.Resource{
+		Description: "Data source describing the Hydra instance the provider is configured for, and what it supports. A feature is only reported as unsupported when the provider could tell for sure.",
+
+		ReadContext: dataSourceHydraInstanceRead,
+
+		Schema: map[string]*schema.Schema{
+			"version": {
+				Description: "The version of Hydra, as shown on its pages, or empty if unknown.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"nix_version": {
+				Description: "The version of Nix Hydra uses, or empty if unknown.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"json_api": {
+				Description: "Whether projects and jobsets can be created and updated with JSON requests, which Hydras without the JSON API answer with their pages instead. If not, the provider uses Hydra's web forms.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"flakes": {
+				Description: "Whether jobsets can be of type `flake`.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"dynamic_run_command": {
+				Description: "Whether projects and jobsets can enable dynamic RunCommand hooks.",
+				Type:        schema.TypeBool,
+				Computed:    true,
+			},
+			"username": {
+				Description: "The name of the Hydra user the provider is logged in as, or empty if it doesn't log in itself (in anonymous mode, or with `auth_mode = \"proxy\"`).",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"roles": {
+				Description: "The roles of the Hydra user the provider is logged in as, e.g. `admin`.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"input_types": {
+				Description: "The types of jobset inputs Hydra supports, or empty if unknown (which requires the provider to be logged in as a project owner or admin, and at least one project to exist).",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func dataSourceHydraInstanceRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	meta := m.(*providerMeta)
+	ctx = meta.logContext(ctx)
+
+	caps := meta.capabilities(ctx)
+
+	d.SetId(meta.host)
+	d.Set("version", caps.Version)
+	d.Set("nix_version", caps.NixVersion)
+	// Writes may have found out that Hydra doesn't accept JSON since.
+	d.Set("json_api", caps.JSONAPI != capabilityUnsupported && !meta.client.JSONRejected())
+	d.Set("flakes", caps.Flakes != capabilityUnsupported)
+	d.Set("dynamic_run_command", caps.DynamicRunCommand != capabilityUnsupported)
+	d.Set("input_types", caps.InputTypes)
+
+	if meta.user != nil {
+		d.Set("username", meta.user.Username)
+		d.Set("roles", meta.user.Roles)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package hydra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestDataSourceHydraInstanceRead(t *testing.T) {
+	server := capabilitiesHydra(t, "0.1.20210305.9bce425", fmt.Sprintf(testJobsetForm, `<input type="radio" name="type" value="1" />`, ""))
+	meta := testMeta(t, server.URL)
+	meta.host = server.URL + "/"
+	meta.user = &hydraUser{Username: "alice", Roles: []string{"admin"}}
+
+	d := schema.TestResourceDataRaw(t, dataSourceHydraInstance().Schema, map[string]interface{}{})
+	if diags := dataSourceHydraInstanceRead(context.Background(), d, meta); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+
+	if d.Id() != server.URL+"/" {
+		t.Errorf("expected the host as the ID, got %q", d.Id())
+	}
+	if d.Get("version").(string) != "0.1.20210305.9bce425" {
+		t.Errorf("unexpected version %q", d.Get("version"))
+	}
+	if !d.Get("flakes").(bool) || d.Get("dynamic_run_command").(bool) {
+		t.Errorf("expected flakes but no dynamic RunCommand")
+	}
+	if !d.Get("json_api").(bool) {
+		t.Errorf("expected the JSON API")
+	}
+	if n := len(d.Get("input_types").([]interface{})); n != 3 {
+		t.Errorf("expected the input types of the form, got %d", n)
+	}
+	if d.Get("username").(string) != "alice" || !reflect.DeepEqual(d.Get("roles"), []interface{}{"admin"}) {
+		t.Errorf("expected the logged in user, got %q with roles %v", d.Get("username"), d.Get("roles"))
+	}
+}
+
+func TestDataSourceHydraInstanceRead_noJSONAPI(t *testing.T) {
+	// Old Hydras show their pages to everyone, whatever they ask for.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html><body>"+testHydraFooter+"</body></html>", "0.1.20191108.4779757")
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, dataSourceHydraInstance().Schema, map[string]interface{}{})
+	if diags := dataSourceHydraInstanceRead(context.Background(), d, testMeta(t, server.URL)); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+
+	if d.Get("json_api").(bool) {
+		t.Errorf("expected no JSON API")
+	}
+	if d.Get("version").(string) != "0.1.20191108.4779757" {
+		t.Errorf("unexpected version %q", d.Get("version"))
+	}
+}
+
+func TestAccHydraInstance_basic(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccHydraInstanceConfig(),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("data.hydra_instance.test", "version"),
+					resource.TestCheckResourceAttr("data.hydra_instance.test", "flakes", "true"),
+					resource.TestCheckResourceAttr("data.hydra_instance.test", "username", os.Getenv("HYDRA_USERNAME")),
+				),
+			},
+		},
+	})
+}
+
+func testAccHydraInstanceConfig() string {
+	return `
+data "hydra_instance" "test" {}
+`
+}
@@ -0,0 +1,116 @@
+package hydra
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// endpoint describes where Hydra can be reached, as derived from the `host`
+// the provider was configured with. Besides plain http(s) URLs, which may point
+// to a Hydra served under a sub-path, `host` can take the form
+//
+//	unix:///run/hydra/hydra.sock?host=hydra.example.com&path=/hydra/
+//
+// to talk to Hydra over a Unix domain socket. The optional `host` and `path`
+// query parameters set the virtual host (sent in the Host and Origin headers)
+// and the sub-path Hydra is served under, and default to `localhost` and `/`.
+type endpoint struct {
+	// URL of the root of Hydra, always ending in a slash. Every API endpoint is
+	// resolved relative to it.
+	Server string
+
+	// Server, without any credentials. Sent as the Referer of state-changing
+	// requests, as Hydra checks that they come from its own base URL.
+	Base string
+
+	// Scheme and host (and port) of the Server, as expected in the Origin header.
+	Origin string
+
+	// Path to the Unix domain socket to dial instead of the host of the Server.
+	Socket string
+}
+
+func parseEndpoint(host string) (*endpoint, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+
+	var socket string
+
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("host %q is missing a host name", host)
+		}
+		u.RawQuery = ""
+		u.Fragment = ""
+	case "unix":
+		if u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+			return nil, fmt.Errorf("host %q must contain an absolute socket path, e.g. unix:///run/hydra/hydra.sock", host)
+		}
+
+		socket = u.Path
+
+		query := u.Query()
+		vhost := query.Get("host")
+		if vhost == "" {
+			vhost = "localhost"
+		}
+
+		u = &url.URL{
+			Scheme: "http",
+			Host:   vhost,
+			Path:   query.Get("path"),
+		}
+	default:
+		return nil, fmt.Errorf("host %q must use the http, https or unix scheme", host)
+	}
+
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	u.RawPath = ""
+
+	base := *u
+	base.User = nil
+
+	return &endpoint{
+		Server: u.String(),
+		Base:   base.String(),
+		Origin: fmt.Sprintf("%s://%s", u.Scheme, u.Host),
+		Socket: socket,
+	}, nil
+}
+
+// URL resolves the path (e.g. "current-user") relative to the root of Hydra.
+func (e *endpoint) URL(path string) string {
+	return e.Server + strings.TrimPrefix(path, "/")
+}
+
+// apply makes the HTTP client dial the Unix domain socket, if there is one.
+func (e *endpoint) apply(retry *retryablehttp.Client) error {
+	if e.Socket == "" {
+		return nil
+	}
+
+	transport, ok := retry.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unexpected HTTP transport type %T", retry.HTTPClient.Transport)
+	}
+
+	socket := e.Socket
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "unix", socket)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package hydra
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"terraform-provider-hydra/hydra/client"
+)
+
+// Hydra refuses state-changing requests whose Origin (or Referer) doesn't match
+// its own base URL with e.g. "POST requests should come from ‘https://...’."
+var csrfErrorRe = regexp.MustCompile(`(?i)requests should come from|\b[cx]srf\b|cross-site request`)
+
+// isCSRFRejection reports whether the response body is Hydra's answer to a
+// request that failed its cross-site request forgery protection.
+func isCSRFRejection(body []byte) bool {
+	return csrfErrorRe.Match(body)
+}
+
+// isNotFound reports whether the error is Hydra saying the object doesn't
+// exist.
+func isNotFound(err error) bool {
+	return errors.Is(err, client.ErrNotFound)
+}
+
+// errorAttribute points the diagnostics for an error whose message matches re
+// at the attribute it is about.
+type errorAttribute struct {
+	re   *regexp.Regexp
+	path cty.Path
+}
+
+// unexpectedResponse builds the diagnostics for a request that didn't get the
+// expected response from Hydra. expected describes what the response should
+// have been. When Hydra rejected the input, the diagnostic points at the first
+// of the attributes whose pattern matches the error message.
+func unexpectedResponse(errsummary string, expected string, err error, attributes ...errorAttribute) diag.Diagnostics {
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		return diag.FromErr(err)
+	}
+
+	message := strings.ReplaceAll(apiErr.Message, "\n", "\n    ")
+
+	if isCSRFRejection(apiErr.Body) {
+		return []diag.Diagnostic{{
+			Severity: diag.Error,
+			Summary:  errsummary,
+			Detail: fmt.Sprintf("Hydra rejected the request as a possible cross-site request forgery, got %s:\n    %s\n\n"+
+				"The Origin header sent by the provider is derived from `host`, so make sure `host` is the "+
+				"exact URL Hydra is served at, and that any reverse proxy in front of Hydra passes the "+
+				"original Host header through.",
+				apiErr.Status, message),
+		}}
+	}
+
+	diagnostic := diag.Diagnostic{
+		Severity: diag.Error,
+		Summary:  errsummary,
+		Detail:   fmt.Sprintf("%s, got %s:\n    %s", expected, apiErr.Status, message),
+	}
+
+	if apiErr.FromHydra || errors.Is(err, client.ErrValidation) || errors.Is(err, client.ErrConflict) {
+		for _, attribute := range attributes {
+			if attribute.re.MatchString(apiErr.Message) {
+				diagnostic.AttributePath = attribute.path
+				break
+			}
+		}
+	}
+
+	return []diag.Diagnostic{diagnostic}
+}
+
+// removedFromState forgets about a resource that was deleted outside of
+// Terraform, so that it is planned to be created again rather than failing the
+// refresh.
+func removedFromState(d *schema.ResourceData, kind string) diag.Diagnostics {
+	id := d.Id()
+	d.SetId("")
+
+	return []diag.Diagnostic{{
+		Severity: diag.Warning,
+		Summary:  fmt.Sprintf("%s no longer exists", kind),
+		Detail: fmt.Sprintf("%s %q was not found in Hydra, so it was removed from the state. "+
+			"It was probably deleted outside of Terraform.", kind, id),
+	}}
+}
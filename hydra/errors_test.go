@@ -0,0 +1,85 @@
+package hydra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"terraform-provider-hydra/hydra/client"
+)
+
+func TestUnexpectedResponse_csrf(t *testing.T) {
+	body := []byte(`{"error":"POST requests should come from ‘https://hydra.example.com/’."}`)
+
+	diags := unexpectedResponse("Failed to create project", "Expected valid project creation response",
+		&client.Error{Status: "500 Internal Server Error", Body: body})
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, "cross-site request forgery") {
+		t.Errorf("expected a CSRF-specific diagnostic, got %+v", diags)
+	}
+
+	diags = unexpectedResponse("Failed to create project", "Expected valid project creation response",
+		&client.Error{Status: "400 Bad Request", Body: []byte(`{"error":"Invalid project identifier"}`)})
+	if len(diags) != 1 || !strings.HasPrefix(diags[0].Detail, "Expected valid project creation response, got 400") {
+		t.Errorf("expected the generic diagnostic, got %+v", diags)
+	}
+}
+
+func TestUnexpectedResponse_attributePath(t *testing.T) {
+	err := &client.Error{
+		Kind:    client.ErrValidation,
+		Status:  "400 Bad Request",
+		Message: "Invalid input type ‘svn’.",
+		Body:    []byte(`{"error":"Invalid input type ‘svn’."}`),
+	}
+
+	diags := unexpectedResponse("Failed to create jobset", "Expected valid jobset creation response", err,
+		jobsetErrorAttributes...)
+	if len(diags) != 1 || !diags[0].AttributePath.Equals(cty.GetAttrPath("input")) {
+		t.Errorf("expected the diagnostic to point at the input, got %+v", diags)
+	}
+	if !strings.HasSuffix(diags[0].Detail, "\n    Invalid input type ‘svn’.") {
+		t.Errorf("expected the detail to contain the message rather than the raw body, got %q", diags[0].Detail)
+	}
+
+	err = &client.Error{
+		Kind:    client.ErrServerError,
+		Status:  "502 Bad Gateway",
+		Message: "Invalid input type",
+		Body:    []byte("Invalid input type"),
+	}
+	diags = unexpectedResponse("Failed to create jobset", "Expected valid jobset creation response", err,
+		jobsetErrorAttributes...)
+	if len(diags) != 1 || diags[0].AttributePath != nil {
+		t.Errorf("expected errors not reported by Hydra not to point at an attribute, got %+v", diags)
+	}
+}
+
+func TestUnexpectedResponse_serverErrorFromHydra(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":"Project foo bar doesn't exist."}`)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"error":"Invalid project identifier ‘foo bar’."}`)
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, resourceHydraProject().Schema, map[string]interface{}{
+		"name":         "foo bar",
+		"display_name": "Foo",
+	})
+
+	diags := resourceHydraProjectCreate(context.Background(), d, testMeta(t, server.URL))
+	if len(diags) != 1 || !diags[0].AttributePath.Equals(cty.GetAttrPath("name")) {
+		t.Errorf("expected the diagnostic to point at the name, got %+v", diags)
+	}
+}
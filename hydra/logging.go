@@ -0,0 +1,189 @@
+package hydra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// The logging subsystems of the provider, whose level can be set separately
+// with e.g. TF_LOG_PROVIDER_HYDRA_HTTP=trace.
+const (
+	subsystemHTTP    = "http"
+	subsystemProject = "project"
+	subsystemJobset  = "jobset"
+	subsystemUser    = "user"
+)
+
+var subsystems = []string{subsystemHTTP, subsystemProject, subsystemJobset, subsystemUser}
+
+// Headers whose values are never logged, as they carry credentials or the
+// session cookie.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Proxy-Authorization": true,
+	"Set-Cookie":          true,
+}
+
+// withLogging sets up the logging subsystems in ctx, and masks the secrets in
+// every message and field logged by the provider through ctx, including those
+// of the subsystems.
+func withLogging(ctx context.Context, secrets ...string) context.Context {
+	var masked []string
+	for _, secret := range secrets {
+		if secret != "" {
+			masked = append(masked, encodedSecret(secret)...)
+		}
+	}
+
+	if len(masked) > 0 {
+		ctx = tflog.MaskAllFieldValuesStrings(ctx, masked...)
+		ctx = tflog.MaskMessageStrings(ctx, masked...)
+	}
+
+	for _, subsystem := range subsystems {
+		ctx = tflog.NewSubsystem(ctx, subsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_HYDRA", subsystem))
+
+		if len(masked) > 0 {
+			ctx = tflog.SubsystemMaskAllFieldValuesStrings(ctx, subsystem, masked...)
+			ctx = tflog.SubsystemMaskMessageStrings(ctx, subsystem, masked...)
+		}
+	}
+
+	return ctx
+}
+
+// encodedSecret returns the forms a secret takes in what's logged: as is, and
+// as encoded in the JSON and form bodies of requests, where e.g. "&" turns into
+// "\u0026" and "%26" respectively.
+func encodedSecret(secret string) []string {
+	forms := []string{secret}
+
+	if encoded, err := json.Marshal(secret); err == nil {
+		forms = append(forms, string(encoded[1:len(encoded)-1]))
+	}
+	forms = append(forms, url.QueryEscape(secret))
+
+	// Mask the longest forms first, so that no part of them is left over
+	// after masking a shorter one they contain.
+	sort.SliceStable(forms, func(i, j int) bool { return len(forms[i]) > len(forms[j]) })
+
+	unique := forms[:0]
+	seen := make(map[string]bool, len(forms))
+	for _, form := range forms {
+		if !seen[form] {
+			seen[form] = true
+			unique = append(unique, form)
+		}
+	}
+	return unique
+}
+
+// loggingTransport logs every request to Hydra and its response to the "http"
+// subsystem: the method, path, status and duration at the debug level, and the
+// headers (without credentials or cookies) and bodies at the trace level. The
+// bodies are only read ahead for that when the subsystem logs at the trace
+// level, so that responses are otherwise streamed as they come.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+// traceHTTP reports whether the "http" subsystem logs at the trace level. The
+// level of a subsystem can't be queried, so this goes by the environment
+// variables that set it, from the most specific one to TF_LOG.
+func traceHTTP() bool {
+	for _, name := range []string{"TF_LOG_PROVIDER_HYDRA_HTTP", "TF_LOG_PROVIDER_HYDRA", "TF_LOG_PROVIDER", "TF_LOG"} {
+		if level := strings.ToUpper(os.Getenv(name)); level != "" {
+			return level == "TRACE" || level == "JSON"
+		}
+	}
+	return false
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	fields := map[string]interface{}{
+		"method": req.Method,
+		"path":   req.URL.Path,
+	}
+
+	trace := traceHTTP()
+
+	var reqBody []byte
+	if trace && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	tflog.SubsystemDebug(ctx, subsystemHTTP, "Sending request to Hydra", fields)
+	tflog.SubsystemTrace(ctx, subsystemHTTP, "Request to Hydra", fields, map[string]interface{}{
+		"headers": loggableHeaders(req.Header),
+		"body":    string(reqBody),
+	})
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields["duration"] = time.Since(start).String()
+
+	if err != nil {
+		fields["error"] = err.Error()
+		tflog.SubsystemDebug(ctx, subsystemHTTP, "Request to Hydra failed", fields)
+		return nil, err
+	}
+
+	fields["status"] = resp.StatusCode
+	tflog.SubsystemDebug(ctx, subsystemHTTP, "Received response from Hydra", fields)
+
+	if !trace {
+		return resp, nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if err != nil {
+		return nil, err
+	}
+
+	tflog.SubsystemTrace(ctx, subsystemHTTP, "Response from Hydra", fields, map[string]interface{}{
+		"headers": loggableHeaders(resp.Header),
+		"body":    string(respBody),
+	})
+
+	return resp, nil
+}
+
+// loggableHeaders formats the headers for the log, masking the values of
+// those that carry credentials. They are formatted as a single string so that
+// the masking of secrets applies to them as well.
+func loggableHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			value = "***"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", name, value)
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package hydra
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+func TestLoggableHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("Authorization", "Bearer secret-token")
+	header.Add("Cookie", "hydra_session=secret-session")
+
+	got := loggableHeaders(header)
+	want := "Accept: application/json\nAuthorization: ***\nCookie: ***\n"
+	if got != want {
+		t.Errorf("loggableHeaders() = %q, want %q", got, want)
+	}
+	if strings.Contains(got, "secret") {
+		t.Errorf("loggableHeaders() leaked a secret: %q", got)
+	}
+}
+
+func TestWithLogging_noLogger(t *testing.T) {
+	// Outside of Terraform there's no logger in the context, which must not
+	// break anything.
+	ctx := withLogging(context.Background(), "", "hunter2")
+	if ctx == nil {
+		t.Fatal("withLogging() returned a nil context")
+	}
+}
+
+func TestLoggingTransport_masksSecrets(t *testing.T) {
+	password := `p<a>ss&"word\`
+	token := "https://example.com/repo.git?a=1&token=s3cr3t"
+	apiKey := "key&<value>"
+	t.Setenv("TF_LOG_PROVIDER_HYDRA_HTTP", "trace")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Echo the request, as Hydra does with e.g. the inputs of jobsets.
+		w.Header().Set("Content-Type", "application/json")
+		io.Copy(w, r.Body)
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	ctx := withLogging(tflogtest.RootLogger(context.Background(), &output), password, token, apiKey)
+
+	login, err := api.NewPostLoginRequest(server.URL, api.PostLoginJSONRequestBody{
+		Username: strPtr("alice"),
+		Password: &password,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	jobset, err := api.NewPutJobsetProjectIdJobsetIdRequest(server.URL, "nixpkgs", "trunk", api.PutJobsetProjectIdJobsetIdJSONRequestBody{
+		Inputs: &map[string]api.JobsetInput{
+			"repo": {Name: strPtr("repo"), Type: strPtr("git"), Value: &token},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	jobset.Header.Set("X-Api-Key", apiKey)
+
+	form, err := http.NewRequest(http.MethodPost, server.URL+"/jobset/nixpkgs/trunk/edit",
+		strings.NewReader(url.Values{"input-repo-value": {token}}.Encode()))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	client := &http.Client{Transport: &loggingTransport{next: http.DefaultTransport}}
+	for _, req := range []*http.Request{login, jobset, form} {
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("expected the requests to be logged")
+	}
+
+	for _, entry := range entries {
+		logged := fmt.Sprint(entry)
+		for _, secret := range []string{password, token, apiKey, "s3cr3t"} {
+			for _, form := range encodedSecret(secret) {
+				if strings.Contains(logged, form) {
+					t.Errorf("secret %q leaked into the log as %q: %s", secret, form, logged)
+				}
+			}
+		}
+	}
+}
+
+func TestLoggingTransport_streamsWithoutTrace(t *testing.T) {
+	t.Setenv("TF_LOG_PROVIDER_HYDRA_HTTP", "debug")
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "[")
+		w.(http.Flusher).Flush()
+		<-release
+		io.WriteString(w, "]")
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	ctx := withLogging(tflogtest.RootLogger(context.Background(), &output))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/evals", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	client := &http.Client{Transport: &loggingTransport{next: http.DefaultTransport}}
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("err: %s", err)
+		}
+		responses <- resp
+	}()
+
+	var resp *http.Response
+	select {
+	case resp = <-responses:
+		close(release)
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatal("expected the response to be returned before its body was complete")
+	}
+	if resp == nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "[]" {
+		t.Errorf("expected the whole body, got %q (%v)", body, err)
+	}
+	if strings.Contains(output.String(), `"body"`) {
+		t.Errorf("expected no bodies to be logged below the trace level: %s", output.String())
+	}
+}
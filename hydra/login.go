@@ -0,0 +1,307 @@
+package hydra
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+
+	"terraform-provider-hydra/hydra/api"
+	hydraclient "terraform-provider-hydra/hydra/client"
+)
+
+// hydraUser is the Hydra user the provider is logged in as.
+type hydraUser struct {
+	Username string
+	Roles    []string
+}
+
+// loginFailure tells apart the ways logging in to Hydra can go wrong, as each
+// of them needs a different fix.
+type loginFailure int
+
+const (
+	// Hydra itself refused the username or password.
+	loginFailureCredentials loginFailure = iota
+	// Whatever answered at `host` isn't (the root of) a Hydra.
+	loginFailureHost
+	// Something between the provider and Hydra got in the way, e.g. a single
+	// sign-on gateway, or a reverse proxy that can't reach Hydra.
+	loginFailureProxy
+	// The TLS handshake failed, e.g. because of an untrusted certificate.
+	loginFailureTLS
+	// Hydra failed to process the login.
+	loginFailureServer
+)
+
+// loginError is returned when logging in to Hydra fails.
+type loginError struct {
+	kind loginFailure
+	msg  string
+	err  error
+}
+
+func (e *loginError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("%s: %s", e.msg, e.err)
+	}
+	return e.msg
+}
+
+func (e *loginError) Unwrap() error {
+	return e.err
+}
+
+func (e *loginError) summary() string {
+	switch e.kind {
+	case loginFailureCredentials:
+		return "Hydra rejected the credentials"
+	case loginFailureHost:
+		return "Hydra was not found at the configured host"
+	case loginFailureProxy:
+		return "A proxy interfered with logging in to Hydra"
+	case loginFailureTLS:
+		return "Failed to establish a TLS connection to Hydra"
+	default:
+		return "Hydra failed to log in"
+	}
+}
+
+func (e *loginError) hint() string {
+	switch e.kind {
+	case loginFailureCredentials:
+		return "Check the username and password (or the credential source they come from)."
+	case loginFailureHost:
+		return "Make sure `host` is the URL Hydra is served at, including any sub-path."
+	case loginFailureProxy:
+		return "If Hydra is behind an authenticating gateway, pass what it expects in `headers` " +
+			"(and consider `auth_mode = \"proxy\"`), or point `host` directly at Hydra."
+	case loginFailureTLS:
+		return "If Hydra uses a self-signed or private certificate, set `ca_cert_file` in the `http` block."
+	default:
+		return "Check the Hydra server logs for details."
+	}
+}
+
+// loginDiagnostics builds the diagnostics for a failed login.
+func loginDiagnostics(err error) diag.Diagnostics {
+	var loginErr *loginError
+	if !errors.As(err, &loginErr) {
+		return []diag.Diagnostic{{
+			Severity: diag.Error,
+			Summary:  "Failed to configure Provider",
+			Detail:   fmt.Sprintf("Failed to log in to Hydra: %s", err),
+		}}
+	}
+
+	return []diag.Diagnostic{{
+		Severity: diag.Error,
+		Summary:  loginErr.summary(),
+		Detail:   fmt.Sprintf("Failed to log in to Hydra: %s\n\n%s", loginErr, loginErr.hint()),
+	}}
+}
+
+// login logs in to Hydra with the configured credentials, which stores the
+// session cookie in the client's cookie jar.
+func (s *session) login(ctx context.Context) error {
+	body := api.PostLoginJSONRequestBody{
+		Username: &s.creds.Username,
+		Password: &s.creds.Password,
+	}
+
+	ctx = context.WithValue(ctx, noReloginKey, true)
+
+	resp, err := s.client.PostLogin(ctx, body)
+	if err != nil {
+		return transportLoginError(err)
+	}
+	defer resp.Body.Close()
+
+	user, err := s.checkLogin(resp)
+	if err != nil {
+		return err
+	}
+
+	s.user = user
+	tflog.Info(ctx, "Logged in to Hydra", map[string]interface{}{
+		"username": user.Username,
+		"roles":    user.Roles,
+	})
+
+	if s.cache != nil {
+		s.store(ctx)
+	}
+
+	return nil
+}
+
+// checkLogin makes sure the response to the login request comes from Hydra
+// and actually started a session.
+func (s *session) checkLogin(resp *http.Response) (*hydraUser, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &loginError{kind: loginFailureServer, msg: "failed to read the response", err: err}
+	}
+
+	status := resp.Status
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	isJSON := strings.Contains(mediaType, "json")
+	isHTML := mediaType == "text/html"
+
+	// The HTTP client follows redirects, so this is where we'd end up on e.g.
+	// the page of a single sign-on provider.
+	if resp.Request != nil && resp.Request.Response != nil {
+		return nil, &loginError{
+			kind: loginFailureProxy,
+			msg:  fmt.Sprintf("the login request was redirected to %s", resp.Request.URL.Redacted()),
+		}
+	}
+
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		return nil, &loginError{
+			kind: loginFailureProxy,
+			msg:  fmt.Sprintf("the login request was redirected (%s) to %q", status, resp.Header.Get("Location")),
+		}
+	case resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") != "":
+		return nil, &loginError{
+			kind: loginFailureProxy,
+			msg:  fmt.Sprintf("got %s, asking for HTTP authentication (%s), which Hydra itself never does", status, resp.Header.Get("WWW-Authenticate")),
+		}
+	case resp.StatusCode == http.StatusForbidden && isCSRFRejection(body):
+		return nil, &loginError{
+			kind: loginFailureHost,
+			msg:  fmt.Sprintf("Hydra rejected the login as a possible cross-site request forgery, as it is served at a different URL: %s", responseExcerpt(body)),
+		}
+	case (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) && isJSON:
+		return nil, &loginError{
+			kind: loginFailureCredentials,
+			msg:  fmt.Sprintf("login as %s (with the password from %s) was rejected: %s", s.creds.Username, s.creds.Source, responseExcerpt(body)),
+		}
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return nil, &loginError{
+			kind: loginFailureHost,
+			msg:  fmt.Sprintf("there is no Hydra login at %s, got %s", resp.Request.URL.Redacted(), status),
+		}
+	case resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusGatewayTimeout:
+		return nil, &loginError{
+			kind: loginFailureProxy,
+			msg:  fmt.Sprintf("got %s, so a proxy in front of Hydra likely couldn't reach it: %s", status, responseExcerpt(body)),
+		}
+	case resp.StatusCode != http.StatusOK:
+		return nil, &loginError{
+			kind: loginFailureServer,
+			msg:  fmt.Sprintf("expected 200, got %s: %s", status, responseExcerpt(body)),
+		}
+	case isHTML:
+		return nil, &loginError{
+			kind: loginFailureProxy,
+			msg:  fmt.Sprintf("got an HTML page instead of Hydra's JSON response: %s", responseExcerpt(body)),
+		}
+	case !isJSON:
+		return nil, &loginError{
+			kind: loginFailureHost,
+			msg:  fmt.Sprintf("expected a JSON response, got %q: %s", resp.Header.Get("Content-Type"), responseExcerpt(body)),
+		}
+	}
+
+	var user struct {
+		Username  string   `json:"username"`
+		Userroles []string `json:"userroles"`
+	}
+	if err := json.Unmarshal(body, &user); err != nil || user.Username == "" {
+		return nil, &loginError{
+			kind: loginFailureHost,
+			msg:  fmt.Sprintf("expected the logged in user, got: %s", responseExcerpt(body)),
+		}
+	}
+
+	cookies := resp.Cookies()
+	if len(cookies) == 0 {
+		return nil, &loginError{
+			kind: loginFailureProxy,
+			msg:  "the response didn't set a session cookie, which a proxy might have removed",
+		}
+	}
+
+	// The cookie jar silently drops cookies that don't apply to the URL, e.g.
+	// secure cookies over plain HTTP, or those for another domain.
+	u, err := url.Parse(s.endpoint.Server)
+	if err != nil {
+		return nil, err
+	}
+	if !hasCookie(s.jar.Cookies(u), cookies) {
+		return nil, &loginError{
+			kind: loginFailureHost,
+			msg:  fmt.Sprintf("the session cookie %q set by Hydra doesn't apply to %s", cookies[0].Name, s.endpoint.Base),
+		}
+	}
+
+	return &hydraUser{
+		Username: user.Username,
+		Roles:    user.Userroles,
+	}, nil
+}
+
+// hasCookie reports whether the jar holds any of the cookies that were set.
+func hasCookie(jar []*http.Cookie, set []*http.Cookie) bool {
+	for _, c := range set {
+		for _, j := range jar {
+			if c.Name == j.Name && c.Value == j.Value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// transportLoginError classifies an error that prevented the login request
+// from getting any response.
+func transportLoginError(err error) error {
+	if isTLSError(err) {
+		return &loginError{kind: loginFailureTLS, msg: "the TLS handshake failed", err: err}
+	}
+
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	if errors.As(err, &dnsErr) || errors.As(err, &opErr) {
+		return &loginError{kind: loginFailureHost, msg: "failed to connect", err: err}
+	}
+
+	return &loginError{kind: loginFailureHost, msg: "the login request failed", err: err}
+}
+
+// isTLSError reports whether the error is due to TLS, e.g. a certificate that
+// couldn't be verified or a server that doesn't speak TLS at all.
+func isTLSError(err error) bool {
+	var verifyErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+
+	return errors.As(err, &verifyErr) ||
+		errors.As(err, &recordErr) ||
+		errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
+}
+
+// responseExcerpt returns the gist of a response body, for use in errors.
+func responseExcerpt(body []byte) string {
+	if msg := hydraclient.DecodeErrorBody("", body); msg != "" {
+		return msg
+	}
+	return "(empty response)"
+}
@@ -0,0 +1,139 @@
+package hydra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSessionLogin_failures(t *testing.T) {
+	cases := map[string]struct {
+		handler http.HandlerFunc
+		kind    loginFailure
+	}{
+		"bad credentials": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `{"error": "Bad username or password."}`)
+			},
+			kind: loginFailureCredentials,
+		},
+		"not hydra": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.NotFound(w, r)
+			},
+			kind: loginFailureHost,
+		},
+		"not json": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				fmt.Fprint(w, "ok")
+			},
+			kind: loginFailureHost,
+		},
+		"sso redirect": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/login" {
+					http.Redirect(w, r, "/sso", http.StatusFound)
+					return
+				}
+				w.Header().Set("Content-Type", "text/html")
+				fmt.Fprint(w, "<html><body>Sign in with your company account</body></html>")
+			},
+			kind: loginFailureProxy,
+		},
+		"html page": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				fmt.Fprint(w, "<html><body>Sign in</body></html>")
+			},
+			kind: loginFailureProxy,
+		},
+		"bad gateway": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				w.WriteHeader(http.StatusBadGateway)
+				fmt.Fprint(w, "<html><body>502 Bad Gateway</body></html>")
+			},
+			kind: loginFailureProxy,
+		},
+		"no session cookie": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, `{"username": "alice"}`)
+			},
+			kind: loginFailureProxy,
+		},
+		"server error": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"error": "DBI connect failed"}`)
+			},
+			kind: loginFailureServer,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(tc.handler)
+			defer server.Close()
+
+			_, sess := newTestSessionClient(t, server.URL)
+
+			err := sess.login(context.Background())
+
+			var loginErr *loginError
+			if !errors.As(err, &loginErr) {
+				t.Fatalf("expected a login error, got %v", err)
+			}
+			if loginErr.kind != tc.kind {
+				t.Errorf("expected failure kind %d, got %d: %s", tc.kind, loginErr.kind, loginErr)
+			}
+		})
+	}
+}
+
+func TestSessionLogin_tls(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, sess := newTestSessionClient(t, server.URL)
+
+	err := sess.login(context.Background())
+
+	var loginErr *loginError
+	if !errors.As(err, &loginErr) || loginErr.kind != loginFailureTLS {
+		t.Fatalf("expected a TLS failure, got %v", err)
+	}
+
+	diags := loginDiagnostics(err)
+	if len(diags) != 1 || diags[0].Summary != "Failed to establish a TLS connection to Hydra" {
+		t.Errorf("unexpected diagnostics: %+v", diags)
+	}
+}
+
+func TestSessionLogin_user(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "hydra_session", Value: "s3cr3t", Path: "/"})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"username": "alice", "userroles": ["admin", "bump-to-front"]}`)
+	}))
+	defer server.Close()
+
+	_, sess := newTestSessionClient(t, server.URL)
+
+	if err := sess.login(context.Background()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &hydraUser{Username: "alice", Roles: []string{"admin", "bump-to-front"}}
+	if !reflect.DeepEqual(sess.user, expected) {
+		t.Errorf("expected the logged in user to be %+v, got %+v", expected, sess.user)
+	}
+}
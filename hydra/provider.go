@@ -1,50 +1,150 @@
 package hydra
 
 import (
+	"bytes"
 	"context"
-	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"regexp"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/logging"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/publicsuffix"
 
 	"terraform-provider-hydra/hydra/api"
+	hydraclient "terraform-provider-hydra/hydra/client"
 )
 
+// The ways the provider can authenticate to Hydra.
+const (
+	authModeLogin = "login"
+	authModeProxy = "proxy"
+	authModeNone  = "none"
+)
+
+// How the provider writes projects and jobsets.
+var writeModes = map[string]hydraclient.WriteMode{
+	"auto": hydraclient.WriteModeAuto,
+	"json": hydraclient.WriteModeJSON,
+	"form": hydraclient.WriteModeForm,
+}
+
 // Provider -
 func Provider() *schema.Provider {
 	return &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"host": {
-				Description: "The address of the Hydra instance.",
+				Description: "The address of the Hydra instance, e.g. `https://hydra.example.com` or `https://ci.example.com/hydra/`. Use `unix:///path/to/hydra.sock?host=hydra.example.com` to connect through a Unix domain socket.",
 				Type:        schema.TypeString,
 				Required:    true,
 				DefaultFunc: schema.EnvDefaultFunc("HYDRA_HOST", nil),
 			},
 			"username": {
-				Description: "The user that Terraform will be logging in as.",
+				Description: "The user that Terraform will be logging in as. May be omitted if the `credential_helper` or the netrc file provides it.",
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("HYDRA_USERNAME", nil),
 			},
 			"password": {
-				Description: "The password for the Hydra user specified in `username`.",
+				Description: "The password for the Hydra user specified in `username`. Takes precedence over all other credential sources.",
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("HYDRA_PASSWORD", nil),
 				Sensitive:   true,
 			},
+			"password_file": {
+				Description: "Path to a file containing the password for the Hydra user. Used when `password` is not set.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HYDRA_PASSWORD_FILE", nil),
+			},
+			"credential_helper": {
+				Description: "Command that prints the credentials as a JSON object `{\"username\": ..., \"password\": ...}` on stdout. Run through the shell, with the `HYDRA_CREDENTIAL_HOST` and `HYDRA_CREDENTIAL_USERNAME` environment variables set. Used when neither `password` nor `password_file` are set.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HYDRA_CREDENTIAL_HELPER", nil),
+			},
+			"netrc_file": {
+				Description: "Path to the netrc file that is searched for the host's credentials when no other credential source is set. Defaults to `$NETRC` or `~/.netrc`.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"auth_mode": {
+				Description: "How the provider authenticates to Hydra: `login` logs in with the Hydra user's credentials, `proxy` relies solely on `headers` (e.g. for Hydra behind an authenticating reverse proxy) and `none` doesn't authenticate at all.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HYDRA_AUTH_MODE", authModeLogin),
+				ValidateFunc: validation.StringInSlice([]string{
+					authModeLogin,
+					authModeProxy,
+					authModeNone,
+				}, false),
+			},
+			"headers": {
+				Description: "Additional HTTP headers sent with every request to Hydra, e.g. `Authorization` for a reverse proxy.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Sensitive:   true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"session_cache_dir": {
+				Description: "Directory in which the Hydra session cookie is cached, so that it can be reused by later invocations of the provider instead of logging in again. Caching is disabled when unset.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HYDRA_SESSION_CACHE_DIR", nil),
+			},
+			"max_concurrent_requests": {
+				Description:  "Maximum number of requests that are sent to Hydra at the same time, regardless of Terraform's `-parallelism`. `0` means unlimited.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HYDRA_MAX_CONCURRENT_REQUESTS", 0),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"requests_per_second": {
+				Description:  "Maximum number of requests that are sent to Hydra per second. `0` means unlimited.",
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HYDRA_REQUESTS_PER_SECOND", 0.0),
+				ValidateFunc: validation.FloatAtLeast(0),
+			},
+			"write_mode": {
+				Description: "How the provider writes projects and jobsets: `json` sends them to Hydra's JSON API, `form` posts them to the forms of Hydra's web interface (for Hydras whose API doesn't accept JSON) and `auto` uses the JSON API until Hydra turns out not to accept it.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HYDRA_WRITE_MODE", "auto"),
+				ValidateFunc: validation.StringInSlice([]string{
+					"auto",
+					"json",
+					"form",
+				}, false),
+			},
+			"http": {
+				Description: "Settings of the HTTP client used to talk to Hydra.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem:        httpSchema(),
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"hydra_project": resourceHydraProject(),
-			"hydra_jobset":  resourceHydraJobset(),
+			"hydra_project":        resourceHydraProject(),
+			"hydra_jobset":         resourceHydraJobset(),
+			"hydra_jobset_trigger": resourceHydraJobsetTrigger(),
+			"hydra_user":           resourceHydraUser(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"hydra_instance": dataSourceHydraInstance(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
@@ -63,83 +163,249 @@ func (c *RetryableHTTPClient) Do(req *http.Request) (*http.Response, error) {
 
 	client := (*retryablehttp.Client)(c)
 
-	return client.Do(r)
+	var span trace.Span
+	var attempts *int
+	r.Request, span, attempts = startRequestSpan(r.Request)
+	resp, err := client.Do(r)
+	endRequestSpan(span, *attempts, resp, err)
+
+	return resp, err
 }
 
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	errsummary := "Failed to configure Provider"
 
 	host := d.Get("host").(string)
-	username := d.Get("username").(string)
-	password := d.Get("password").(string)
+	authMode := d.Get("auth_mode").(string)
+
+	// Everything that must never show up in the logs.
+	var secrets []string
+
+	headers := make(map[string]string)
+	for k, v := range d.Get("headers").(map[string]interface{}) {
+		headers[k] = v.(string)
+		secrets = append(secrets, v.(string))
+	}
+
+	endpoint, err := parseEndpoint(host)
+	if err != nil {
+		return nil, []diag.Diagnostic{{
+			Severity: diag.Error,
+			Summary:  errsummary,
+			Detail:   fmt.Sprintf("Invalid host: %s", err),
+		}}
+	}
+	if u, err := url.Parse(endpoint.Server); err == nil {
+		if password, ok := u.User.Password(); ok {
+			secrets = append(secrets, password)
+		}
+	}
+
+	if authMode == authModeProxy && len(headers) == 0 {
+		return nil, []diag.Diagnostic{{
+			Severity: diag.Error,
+			Summary:  errsummary,
+			Detail:   "auth_mode \"proxy\" requires the authentication header(s) to be set in `headers`.",
+		}}
+	}
+
+	// Without any credentials at all, the provider can still read from Hydra.
+	anonymous := authMode == authModeNone
+
+	var creds *credentials
+	if authMode == authModeLogin {
+		sources := credentialSources{
+			Host:             endpoint.Server,
+			Username:         d.Get("username").(string),
+			Password:         d.Get("password").(string),
+			PasswordFile:     d.Get("password_file").(string),
+			CredentialHelper: d.Get("credential_helper").(string),
+			NetrcFile:        d.Get("netrc_file").(string),
+		}
+
+		creds, err = sources.resolve(ctx)
+		if errors.Is(err, errNoCredentials) && sources.Username == "" {
+			tflog.Info(ctx, "No Hydra credentials configured, running in anonymous read-only mode")
+			anonymous = true
+		} else if err != nil {
+			return nil, []diag.Diagnostic{{
+				Severity: diag.Error,
+				Summary:  errsummary,
+				Detail:   fmt.Sprintf("Unable to determine the credentials of the Hydra user: %s", err),
+			}}
+		} else {
+			tflog.Info(ctx, "Resolved Hydra credentials", map[string]interface{}{
+				"username": creds.Username,
+				"source":   creds.Source,
+			})
+			secrets = append(secrets, creds.Password)
+		}
+	}
+
+	ctx = withLogging(ctx, secrets...)
 
 	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
+	httpConfig, err := expandHTTPConfig(d.Get("http").([]interface{}))
+	if err != nil {
+		return nil, []diag.Diagnostic{{
+			Severity: diag.Error,
+			Summary:  errsummary,
+			Detail:   err.Error(),
+		}}
+	}
+
 	retry := retryablehttp.NewClient()
-	retry.RetryWaitMin = time.Second
-	retry.RetryWaitMax = 30 * time.Second
-	retry.RetryMax = 10
+	if err := httpConfig.apply(retry); err != nil {
+		return nil, []diag.Diagnostic{{
+			Severity: diag.Error,
+			Summary:  errsummary,
+			Detail:   err.Error(),
+		}}
+	}
+	if err := endpoint.apply(retry); err != nil {
+		return nil, diag.FromErr(err)
+	}
 	retry.CheckRetry = retryPolicy
+	retry.Backoff = retryBackoff
+	retry.ErrorHandler = retryablehttp.PassthroughErrorHandler
 	retry.Logger = nil
 	retry.HTTPClient.Jar = jar
-	retry.HTTPClient.Transport = logging.NewTransport(
-		"DeterminateSystems/terraform-provider-hydra",
+	retry.HTTPClient.Transport = &loggingTransport{next: retry.HTTPClient.Transport}
+	retry.HTTPClient.Transport = newThrottledTransport(
 		retry.HTTPClient.Transport,
+		d.Get("max_concurrent_requests").(int),
+		d.Get("requests_per_second").(float64),
 	)
+	retry.HTTPClient.Transport = &tracingTransport{next: retry.HTTPClient.Transport}
 
 	httpclient := (*RetryableHTTPClient)(retry)
 
-	client, err := api.NewClientWithResponses(host, func(c *api.Client) error {
-		c.Client = httpclient
-		c.RequestEditors = append(c.RequestEditors,
-			func(ctx context.Context, req *http.Request) error {
-				req.Header.Add("Accept", "application/json")
-				req.Header.Add("Content-Type", "application/json")
-				return nil
-			})
+	editors := []api.RequestEditorFn{
+		func(ctx context.Context, req *http.Request) error {
+			req.Header.Add("Accept", "application/json")
+			req.Header.Add("Content-Type", "application/json")
+			return nil
+		},
+		headersEditor(headers),
+		originEditor(endpoint),
+	}
+
+	// Only a provider that logs in itself can recover from an expired session.
+	var doer api.HttpRequestDoer = httpclient
+	var sess *session
+	if creds != nil {
+		sess = &session{
+			creds:    creds,
+			endpoint: endpoint,
+			jar:      jar,
+			doer:     httpclient,
+			editors:  editors,
+		}
+
+		if dir := d.Get("session_cache_dir").(string); dir != "" {
+			sess.cache = &sessionCache{dir: dir}
+		}
+
+		doer = &sessionDoer{
+			next:    httpclient,
+			session: sess,
+		}
+	}
+
+	client, err := api.NewClientWithResponses(endpoint.Server, func(c *api.Client) error {
+		c.Client = doer
+		c.RequestEditors = append(c.RequestEditors, editors...)
 		return nil
 	})
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
 
-	body := api.PostLoginJSONRequestBody{
-		Username: &username,
-		Password: &password,
-	}
+	if sess != nil {
+		sess.client = client
 
-	resp, err := client.PostLoginWithResponse(ctx, body,
-		func(ctx context.Context, req *http.Request) error {
-			origin, err := url.Parse(host)
-			if err != nil {
-				return err
-			}
+		if err := sess.start(ctx); err != nil {
+			return nil, loginDiagnostics(err)
+		}
+	}
 
-			// Set the User field to nil in order to strip out authentication information
-			// from the URI -- Hydra expects *only* the host (and port, if necessary) as
-			// the Origin.
-			origin.User = nil
+	hc := hydraclient.New(client)
+	hc.WriteMode = writeModes[d.Get("write_mode").(string)]
 
-			req.Header.Add("Origin", origin.String())
-			return nil
-		})
-	if err != nil {
-		return nil, diag.FromErr(err)
+	meta := &providerMeta{
+		client:    hc,
+		host:      endpoint.Base,
+		anonymous: anonymous,
+		secrets:   secrets,
+	}
+	if sess != nil {
+		meta.user = sess.user
 	}
-	defer resp.HTTPResponse.Body.Close()
 
-	if resp.JSON403 != nil {
-		return nil, []diag.Diagnostic{{
-			Severity: diag.Error,
-			Summary:  errsummary,
-			Detail:   *resp.JSON403.Error,
-		}}
+	// Find out what Hydra supports up front, for checking plans against it.
+	meta.capabilities(meta.logContext(ctx))
+
+	return meta, nil
+}
+
+// providerMeta is the state shared by all resources of a configured provider.
+type providerMeta struct {
+	client *hydraclient.Client
+
+	// The URL of Hydra, as configured in `host`.
+	host string
+
+	// Whether the provider has no credentials and can thus only read from Hydra.
+	anonymous bool
+
+	// The user the provider is logged in as, if it logged in itself.
+	user *hydraUser
+
+	// The password and other secrets of the provider configuration, which are
+	// masked in the logs.
+	secrets []string
+
+	// What Hydra supports, see capabilities.
+	capsOnce sync.Once
+	caps     *hydraCapabilities
+}
+
+// logContext sets up logging for a resource operation in ctx, masking the
+// secrets of the provider configuration as well as the given ones (e.g. the
+// values of sensitive inputs).
+func (m *providerMeta) logContext(ctx context.Context, secrets ...string) context.Context {
+	return withLogging(ctx, append(append([]string(nil), m.secrets...), secrets...)...)
+}
+
+// requireWrite fails when the provider can't make changes to Hydra, before the
+// resource even tries to.
+func (m *providerMeta) requireWrite(errsummary string) diag.Diagnostics {
+	if !m.anonymous {
+		return nil
 	}
 
-	return client, nil
+	return []diag.Diagnostic{{
+		Severity: diag.Error,
+		Summary:  errsummary,
+		Detail: "The provider is in anonymous mode and can only read from Hydra. " +
+			"Configure the credentials of a Hydra user (`username` and `password`, or one of the " +
+			"other credential sources) to create, update or delete resources.",
+	}}
+}
+
+// headersEditor adds the static headers configured on the provider (e.g. the
+// token expected by an authenticating reverse proxy) to every request.
+func headersEditor(headers map[string]string) api.RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return nil
+	}
 }
 
 // https://github.com/packethost/terraform-provider-packet/blob/c57d85cfe55288a87b51938ff8909fdbf932a5af/packet/config.go#L24
@@ -158,7 +424,7 @@ func retryPolicy(ctx context.Context, resp *http.Response, err error) (bool, err
 			}
 
 			// Don't retry if the error was due to TLS cert verification failure.
-			if _, ok := v.Err.(x509.UnknownAuthorityError); ok {
+			if isTLSError(v.Err) {
 				return false, nil
 			}
 		}
@@ -167,5 +433,96 @@ func retryPolicy(ctx context.Context, resp *http.Response, err error) (bool, err
 		return true, nil
 	}
 
-	return false, nil
+	// Only replay requests that are safe to send more than once. In particular,
+	// a POST /login that got an answer (good or bad) must not be sent again.
+	if resp.Request == nil || !idempotentMethods[resp.Request.Method] {
+		return false, nil
+	}
+
+	// Hydra reports the errors it runs into handling a request, e.g. an
+	// invalid project name, as a 500 with the error in the body. Sending the
+	// same request again gets the same error.
+	if resp.StatusCode == http.StatusInternalServerError && isHydraError(resp) {
+		return false, nil
+	}
+
+	return retryableStatusCodes[resp.StatusCode], nil
+}
+
+// isHydraError reports whether the response carries an error reported by
+// Hydra itself. The body is read, and replaced for whoever reads it next.
+func isHydraError(resp *http.Response) bool {
+	if resp.Body == nil {
+		return false
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+
+	return hydraclient.IsHydraError(resp.Header.Get("Content-Type"), body)
+}
+
+// Methods that can be repeated without changing the outcome on the Hydra side.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodHead:   true,
+	http.MethodPut:    true,
+	http.MethodDelete: true,
+}
+
+// Status codes that indicate a transient failure, e.g. while Hydra (or the
+// reverse proxy in front of it) is restarting.
+var retryableStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// retryBackoff waits for as long as the server asked us to with the
+// Retry-After header (capped at max), and otherwise falls back to the default
+// exponential backoff.
+func retryBackoff(min, max time.Duration, attemptNum int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if wait > max {
+				wait = max
+			}
+			return wait
+		}
+	}
+
+	return retryablehttp.DefaultBackoff(min, max, attemptNum, resp)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	wait := time.Until(date)
+	if wait < 0 {
+		wait = 0
+	}
+
+	return wait, true
 }
@@ -1,10 +1,22 @@
 package hydra
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"terraform-provider-hydra/hydra/api"
+	hydraclient "terraform-provider-hydra/hydra/client"
 )
 
 var testAccProvider *schema.Provider
@@ -28,6 +40,253 @@ func TestProvider_impl(t *testing.T) {
 	var _ *schema.Provider = Provider()
 }
 
+func TestRetryPolicy(t *testing.T) {
+	cases := []struct {
+		method string
+		status int
+		retry  bool
+	}{
+		{http.MethodGet, http.StatusOK, false},
+		{http.MethodGet, http.StatusNotFound, false},
+		{http.MethodGet, http.StatusBadGateway, true},
+		{http.MethodGet, http.StatusTooManyRequests, true},
+		{http.MethodPut, http.StatusServiceUnavailable, true},
+		{http.MethodPut, http.StatusBadRequest, false},
+		{http.MethodDelete, http.StatusGatewayTimeout, true},
+		{http.MethodDelete, http.StatusInternalServerError, true},
+		{http.MethodPost, http.StatusBadGateway, false},
+		{http.MethodPost, http.StatusTooManyRequests, false},
+	}
+
+	for _, c := range cases {
+		req, _ := http.NewRequest(c.method, "https://hydra.example.com/", nil)
+		resp := &http.Response{StatusCode: c.status, Request: req}
+
+		retry, err := retryPolicy(context.Background(), resp, nil)
+		if err != nil {
+			t.Fatalf("%s %d: unexpected error: %s", c.method, c.status, err)
+		}
+		if retry != c.retry {
+			t.Errorf("%s %d: expected retry to be %t, got %t", c.method, c.status, c.retry, retry)
+		}
+	}
+}
+
+func TestRetryPolicy_hydraError(t *testing.T) {
+	body := `{"error":"Invalid project identifier ‘foo bar’."}`
+	req, _ := http.NewRequest(http.MethodPut, "https://hydra.example.com/project/foo%20bar", nil)
+	resp := &http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+
+	retry, err := retryPolicy(context.Background(), resp, nil)
+	if err != nil || retry {
+		t.Errorf("expected an error reported by Hydra not to be retried, got %t (%v)", retry, err)
+	}
+
+	// The body is still there for the error message.
+	if got, _ := io.ReadAll(resp.Body); string(got) != body {
+		t.Errorf("expected the body to be kept, got %q", got)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	min, max := time.Second, 30*time.Second
+
+	resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "5")
+	if wait := retryBackoff(min, max, 0, resp); wait != 5*time.Second {
+		t.Errorf("expected Retry-After of 5s to be honored, got %s", wait)
+	}
+
+	resp.Header.Set("Retry-After", "3600")
+	if wait := retryBackoff(min, max, 0, resp); wait != max {
+		t.Errorf("expected Retry-After to be capped at %s, got %s", max, wait)
+	}
+
+	resp.Header.Set("Retry-After", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	if wait := retryBackoff(min, max, 0, resp); wait != 0 {
+		t.Errorf("expected Retry-After in the past to not wait, got %s", wait)
+	}
+
+	resp.Header.Del("Retry-After")
+	if wait := retryBackoff(min, max, 2, resp); wait != 4*time.Second {
+		t.Errorf("expected exponential backoff of 4s, got %s", wait)
+	}
+}
+
+// recordingHydra answers every request successfully and remembers the path and
+// headers of each of them.
+type recordingHydra struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (f *recordingHydra) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r)
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/login" {
+		http.SetCookie(w, &http.Cookie{Name: "hydra_session", Value: "s3cr3t", Path: "/"})
+		fmt.Fprint(w, `{"username": "alice"}`)
+		return
+	}
+	fmt.Fprint(w, `{"name": "nixpkgs"}`)
+}
+
+func (f *recordingHydra) paths() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var paths []string
+	for _, r := range f.requests {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+	}
+	return paths
+}
+
+func testProviderConfigure(t *testing.T, raw map[string]interface{}) *api.ClientWithResponses {
+	t.Helper()
+
+	d := schema.TestResourceDataRaw(t, Provider().Schema, raw)
+	meta, diags := providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("failed to configure the provider: %+v", diags)
+	}
+
+	return meta.(*providerMeta).client.ClientWithResponses
+}
+
+// testMeta returns the meta of a provider talking to the given test server,
+// without logging in.
+func testMeta(t *testing.T, host string) *providerMeta {
+	t.Helper()
+
+	c, err := api.NewClientWithResponses(host + "/")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return &providerMeta{client: hydraclient.New(c)}
+}
+
+func TestProviderConfigure_proxyAuth(t *testing.T) {
+	fake := &recordingHydra{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := testProviderConfigure(t, map[string]interface{}{
+		"host":      server.URL,
+		"auth_mode": "proxy",
+		"headers": map[string]interface{}{
+			"Authorization": "Bearer t0k3n",
+		},
+	})
+
+	get, err := client.GetProjectIdWithResponse(context.Background(), "nixpkgs")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if get.StatusCode() != http.StatusOK {
+		t.Fatalf("unexpected response: %s", get.Status())
+	}
+
+	// Configuring the provider probes Hydra's capabilities.
+	want := []string{"GET /", "GET /", "GET /project/nixpkgs"}
+	if paths := fake.paths(); !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected requests %v without logging in, got %v", want, paths)
+	}
+	for _, r := range fake.requests {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer t0k3n" {
+			t.Errorf("expected the configured header to be sent, got %q", auth)
+		}
+	}
+}
+
+func TestProviderConfigure_loginWithHeaders(t *testing.T) {
+	fake := &recordingHydra{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	testProviderConfigure(t, map[string]interface{}{
+		"host":     server.URL,
+		"username": "alice",
+		"password": "foobar",
+		"headers": map[string]interface{}{
+			"X-Gateway-Token": "t0k3n",
+		},
+	})
+
+	want := []string{"POST /login", "GET /", "GET /"}
+	if paths := fake.paths(); !reflect.DeepEqual(paths, want) {
+		t.Fatalf("expected requests %v, got %v", want, paths)
+	}
+	if token := fake.requests[0].Header.Get("X-Gateway-Token"); token != "t0k3n" {
+		t.Errorf("expected the configured header on the login request, got %q", token)
+	}
+}
+
+func TestProviderConfigure_proxyAuthRequiresHeaders(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"host":      "https://hydra.example.com",
+		"auth_mode": "proxy",
+	})
+
+	if _, diags := providerConfigure(context.Background(), d); !diags.HasError() {
+		t.Errorf("expected auth_mode \"proxy\" without headers to be rejected")
+	}
+}
+
+func TestProviderConfigure_anonymous(t *testing.T) {
+	fake := &recordingHydra{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	for _, env := range []string{"HYDRA_USERNAME", "HYDRA_PASSWORD", "HYDRA_PASSWORD_FILE", "HYDRA_CREDENTIAL_HELPER"} {
+		t.Setenv(env, "")
+	}
+
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"host":       server.URL,
+		"netrc_file": writeTestFile(t, "netrc", ""),
+	})
+	meta, diags := providerConfigure(context.Background(), d)
+	if diags.HasError() {
+		t.Fatalf("failed to configure the provider: %+v", diags)
+	}
+
+	if !meta.(*providerMeta).anonymous {
+		t.Fatalf("expected the provider to be in anonymous mode")
+	}
+
+	get, err := meta.(*providerMeta).client.GetProjectIdWithResponse(context.Background(), "nixpkgs")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if get.StatusCode() != http.StatusOK {
+		t.Fatalf("unexpected response: %s", get.Status())
+	}
+
+	project := schema.TestResourceDataRaw(t, resourceHydraProject().Schema, map[string]interface{}{
+		"name":         "nixpkgs",
+		"display_name": "Nixpkgs",
+	})
+	diags = resourceHydraProjectCreate(context.Background(), project, meta)
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "anonymous mode") {
+		t.Errorf("expected creating a project to fail in anonymous mode, got %+v", diags)
+	}
+
+	want := []string{"GET /", "GET /", "GET /project/nixpkgs"}
+	if paths := fake.paths(); !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected only the probe and the read request, without logging in, got %v", paths)
+	}
+}
+
 func testAccPreCheck(t *testing.T) {
 	if v := os.Getenv("HYDRA_HOST"); v == "" {
 		t.Fatal("HYDRA_HOST must be set for acceptance tests\n",
@@ -2,15 +2,22 @@ package hydra
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.opentelemetry.io/otel/attribute"
 
 	"terraform-provider-hydra/hydra/api"
+	hydraclient "terraform-provider-hydra/hydra/client"
 )
 
 func nixExprSchema() *schema.Resource {
@@ -54,21 +61,68 @@ func inputSchema() *schema.Resource {
 				Optional:    true,
 				Default:     false,
 			},
+			"sensitive": {
+				Description: "Whether or not `value` is a secret (e.g. an access token), which the provider then masks in its logs. This is not sent to Hydra.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+		},
+	}
+}
+
+func waitForEvaluationSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"enabled": {
+				Description: "Whether or not to wait for the evaluation.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+			},
+			"timeout": {
+				Description:  "How long to wait for the evaluation, e.g. `30m`.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "15m",
+				ValidateFunc: validateDuration,
+			},
+			"fail_on_eval_error": {
+				Description: "Whether or not to fail the apply with Hydra's error when the evaluation fails, rather than warn about it.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
 		},
 	}
 }
 
+// The attributes that Hydra's errors about invalid jobsets refer to, e.g.
+// "Invalid jobset identifier ‘foo bar’." or "Invalid input type ‘svn’."
+var jobsetErrorAttributes = []errorAttribute{
+	{regexp.MustCompile(`(?i)jobset (identifier|name)|jobset .* already exists|identifier is already taken`), cty.GetAttrPath("name")},
+	{regexp.MustCompile(`(?i)project`), cty.GetAttrPath("project")},
+	{regexp.MustCompile(`(?i)\binputs?\b`), cty.GetAttrPath("input")},
+	{regexp.MustCompile(`(?i)nix ?expr`), cty.GetAttrPath("nix_expression")},
+	{regexp.MustCompile(`(?i)flake`), cty.GetAttrPath("flake_uri")},
+	{regexp.MustCompile(`(?i)check ?interval`), cty.GetAttrPath("check_interval")},
+	{regexp.MustCompile(`(?i)shares`), cty.GetAttrPath("scheduling_shares")},
+	{regexp.MustCompile(`(?i)keepnr|evaluations to keep`), cty.GetAttrPath("keep_evaluations")},
+	{regexp.MustCompile(`(?i)email ?override`), cty.GetAttrPath("email_override")},
+}
+
 func resourceHydraJobset() *schema.Resource {
 	return &schema.Resource{
 		Description: "Resource defining a Hydra jobset.",
 
-		CreateContext: resourceHydraJobsetCreate,
-		ReadContext:   resourceHydraJobsetRead,
-		UpdateContext: resourceHydraJobsetUpdate,
-		DeleteContext: resourceHydraJobsetDelete,
+		CreateContext: traced("hydra_jobset.create", jobsetSpanAttributes, resourceHydraJobsetCreate),
+		ReadContext:   traced("hydra_jobset.read", jobsetSpanAttributes, resourceHydraJobsetRead),
+		UpdateContext: traced("hydra_jobset.update", jobsetSpanAttributes, resourceHydraJobsetUpdate),
+		DeleteContext: traced("hydra_jobset.delete", jobsetSpanAttributes, resourceHydraJobsetDelete),
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: resourceHydraJobsetCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"project": {
@@ -157,6 +211,12 @@ func resourceHydraJobset() *schema.Resource {
 				Type:        schema.TypeInt,
 				Required:    true,
 			},
+			"enable_dynamic_run_command": {
+				Description: "Whether or not the jobset may run dynamically defined RunCommand hooks. The project must allow them too (see `hydra_project`'s `enable_dynamic_run_command`).",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
 			"input": {
 				Description: "Input(s) provided to the jobset.",
 				Type:        schema.TypeSet,
@@ -164,6 +224,48 @@ func resourceHydraJobset() *schema.Resource {
 				MinItems:    1,
 				Elem:        inputSchema(),
 			},
+			"wait_for_evaluation": {
+				Description: "Wait for Hydra to evaluate the jobset after creating or changing it, until a new evaluation appears or the evaluation fails. Disabled jobsets aren't waited for.",
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem:        waitForEvaluationSchema(),
+			},
+			"latest_evaluation_id": {
+				Description: "The ID of the jobset's latest evaluation, or 0 if it has none. Only kept track of when `wait_for_evaluation` is enabled.",
+				Type:        schema.TypeInt,
+				Computed:    true,
+			},
+			"error_message": {
+				Description: "The error of the jobset's last evaluation, or empty if it succeeded.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"error_time": {
+				Description: "When `error_message` was set (RFC 3339), or empty.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"fetch_error_message": {
+				Description: "The error fetching the jobset's inputs during its last evaluation, or empty if there was none.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"last_checked_time": {
+				Description: "When the evaluator last checked the jobset (RFC 3339), or empty if it never did.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"trigger_time": {
+				Description: "When the jobset was last triggered, e.g. by a push event (RFC 3339), or empty.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+			"start_time": {
+				Description: "When the jobset's running evaluation started (RFC 3339), or empty if none is running.",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
 		},
 	}
 }
@@ -283,6 +385,11 @@ func createJobsetPutBody(project string, jobset string, d *schema.ResourceData)
 		body.Emailoverride = &emailOverride
 	}
 
+	dynamicRunCommand := d.Get("enable_dynamic_run_command").(bool)
+	if dynamicRunCommand {
+		body.EnableDynamicRunCommand = &dynamicRunCommand
+	}
+
 	flakeURI := d.Get("flake_uri").(string)
 	if jobsetType == 1 && flakeURI == "" {
 		diags = append(diags, diag.Diagnostic{
@@ -371,35 +478,127 @@ func resourceHydraJobsetParseID(id string) (string, string, error) {
 	return parts[0], parts[1], nil
 }
 
+// resourceHydraJobsetCustomizeDiff rejects what the Hydra is known not to
+// support at plan time, rather than failing halfway through the apply.
+func resourceHydraJobsetCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	// Applying a change to the jobset might lead to a new evaluation.
+	if evaluationWaitEnabled(d.Get("wait_for_evaluation").([]interface{})) {
+		for _, key := range d.GetChangedKeysPrefix("") {
+			if !strings.HasPrefix(key, "wait_for_evaluation") {
+				if err := d.SetNewComputed("latest_evaluation_id"); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	meta, ok := m.(*providerMeta)
+	if !ok || meta == nil {
+		return nil
+	}
+	ctx = meta.logContext(ctx)
+	caps := meta.capabilities(ctx)
+
+	if d.Get("type").(string) == "flake" && caps.Flakes == capabilityUnsupported {
+		return fmt.Errorf("type: %s doesn't support flake jobsets", caps.name())
+	}
+
+	for _, value := range d.Get("input").(*schema.Set).List() {
+		inputType := value.(map[string]interface{})["type"].(string)
+		if inputType != "" && !caps.supportsInputType(inputType) {
+			return fmt.Errorf("input: %s doesn't support inputs of type %q, only %s",
+				caps.name(), inputType, strings.Join(caps.InputTypes, ", "))
+		}
+	}
+
+	if d.Get("enable_dynamic_run_command").(bool) {
+		if caps.DynamicRunCommand == capabilityUnsupported {
+			return fmt.Errorf("enable_dynamic_run_command: %s doesn't support dynamic RunCommand", caps.name())
+		}
+		if d.NewValueKnown("project") {
+			if err := checkProjectAllowsDynamicRunCommand(ctx, meta, d.Get("project").(string)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkProjectAllowsDynamicRunCommand rejects enabling dynamic RunCommand on a
+// jobset whose project doesn't allow it. A project that doesn't exist yet is
+// assumed to be created with the jobset, and Hydra gets to decide about it.
+func checkProjectAllowsDynamicRunCommand(ctx context.Context, meta *providerMeta, project string) error {
+	p, err := meta.client.GetProject(ctx, project)
+	if err != nil {
+		if !isNotFound(err) {
+			tflog.SubsystemDebug(ctx, subsystemJobset, "Failed to check the project's dynamic RunCommand setting", map[string]interface{}{
+				"project": project,
+				"error":   err.Error(),
+			})
+		}
+		return nil
+	}
+
+	if !boolOr(p.EnableDynamicRunCommand, false) {
+		return fmt.Errorf("enable_dynamic_run_command: project %q doesn't allow dynamic RunCommand, "+
+			"set enable_dynamic_run_command on the project (and apply that) first", project)
+	}
+
+	return nil
+}
+
+// jobsetSpanAttributes identifies the jobset in the spans of its operations.
+func jobsetSpanAttributes(d *schema.ResourceData) []attribute.KeyValue {
+	project, jobset, err := resourceHydraJobsetParseID(d.Id())
+	if err != nil {
+		project = d.Get("project").(string)
+		jobset = d.Get("name").(string)
+	}
+
+	return []attribute.KeyValue{
+		attribute.String("hydra.project", project),
+		attribute.String("hydra.jobset", jobset),
+	}
+}
+
 func resourceHydraJobsetCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	errsummary := "Failed to create jobset"
-	client := m.(*api.ClientWithResponses)
+	meta := m.(*providerMeta)
+	if diags := meta.requireWrite(errsummary); diags != nil {
+		return diags
+	}
+	client := meta.client
+	ctx = meta.logContext(ctx, sensitiveInputValues(d)...)
 
 	project := d.Get("project").(string)
-	getproj, err := client.GetProjectIdWithResponse(ctx, project)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-	defer getproj.HTTPResponse.Body.Close()
 
 	// Check to make sure the parent project exists
-	if getproj.HTTPResponse.StatusCode == http.StatusNotFound {
+	_, err := client.GetProject(ctx, project)
+	if isNotFound(err) {
 		return []diag.Diagnostic{{
 			Severity: diag.Error,
 			Summary:  errsummary,
 			Detail:   "Parent project does not exist.",
 		}}
 	}
-
-	jobset := d.Get("name").(string)
-	getjob, err := client.GetJobsetProjectIdJobsetIdWithResponse(ctx, project, jobset)
 	if err != nil {
-		return diag.FromErr(err)
+		return unexpectedResponse(errsummary, "Expected valid response from parent project", err)
 	}
-	defer getjob.HTTPResponse.Body.Close()
+
+	jobset := d.Get("name").(string)
+	tflog.SubsystemInfo(ctx, subsystemJobset, "Creating jobset", map[string]interface{}{
+		"project": project,
+		"jobset":  jobset,
+	})
 
 	// Check to make sure the jobset doesn't yet exist
-	if getjob.HTTPResponse.StatusCode != http.StatusNotFound {
+	exists, err := client.JobsetExists(ctx, project, jobset)
+	if err != nil {
+		return unexpectedResponse(errsummary, "Expected valid response when checking for an existing jobset", err)
+	}
+	if exists {
 		return []diag.Diagnostic{{
 			Severity: diag.Error,
 			Summary:  errsummary,
@@ -413,31 +612,27 @@ func resourceHydraJobsetCreate(ctx context.Context, d *schema.ResourceData, m in
 		return diags
 	}
 
-	put, err := client.PutJobsetProjectIdJobsetIdWithResponse(ctx, project, jobset, *body)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-	defer put.HTTPResponse.Body.Close()
+	// Hydra's timestamps are in seconds.
+	since := time.Now().Truncate(time.Second)
 
 	// If we didn't get the expected response, show what went wrong
-	if put.JSON201 == nil {
-		return []diag.Diagnostic{{
-			Severity: diag.Error,
-			Summary:  errsummary,
-			Detail: fmt.Sprintf("Expected valid jobset creation response, got %s:\n    %s",
-				put.Status(), string(put.Body)),
-		}}
+	if err := client.CreateJobset(ctx, project, jobset, *body); err != nil {
+		return unexpectedResponse(errsummary, "Expected valid jobset creation response", err,
+			jobsetErrorAttributes...)
 	}
 
 	id := fmt.Sprintf("%s/%s", project, jobset)
 	d.SetId(id)
 
-	return nil
+	// A new jobset has no evaluations yet.
+	return awaitJobsetEvaluation(ctx, d, client, project, jobset, 0, since, errsummary)
 }
 
 func resourceHydraJobsetRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	errsummary := "Failed to read Jobset"
-	client := m.(*api.ClientWithResponses)
+	meta := m.(*providerMeta)
+	client := meta.client
+	ctx = meta.logContext(ctx, sensitiveInputValues(d)...)
 
 	id := d.Id()
 
@@ -446,82 +641,50 @@ func resourceHydraJobsetRead(ctx context.Context, d *schema.ResourceData, m inte
 		return diag.FromErr(err)
 	}
 
-	get, err := client.GetJobsetProjectIdJobsetIdWithResponse(ctx, project, jobset)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-	defer get.HTTPResponse.Body.Close()
+	tflog.SubsystemDebug(ctx, subsystemJobset, "Reading jobset", map[string]interface{}{
+		"project": project,
+		"jobset":  jobset,
+	})
 
-	// Check to make sure the jobset exists
-	if get.HTTPResponse.StatusCode != http.StatusOK {
-		d.SetId("")
-		return []diag.Diagnostic{{
-			Severity: diag.Error,
-			Summary:  errsummary,
-			Detail: fmt.Sprintf("Expected valid response from existing jobset, got %s:\n    %s",
-				get.Status(), string(get.Body)),
-		}}
+	jobsetResponse, err := client.GetJobset(ctx, project, jobset)
+	if isNotFound(err) {
+		return removedFromState(d, "Jobset")
 	}
-
-	jobsetResponse := get.JSON200
-
-	state := stateToString(*jobsetResponse.Enabled)
-	jobsetType := jobsetTypeToString(*jobsetResponse.Type)
-
-	d.Set("project", *jobsetResponse.Project)
-	d.Set("name", *jobsetResponse.Name)
-	d.Set("state", state)
-	d.Set("type", jobsetType)
-	d.Set("description", *jobsetResponse.Description)
-	d.Set("check_interval", *jobsetResponse.Checkinterval)
-	d.Set("scheduling_shares", *jobsetResponse.Schedulingshares)
-	d.Set("keep_evaluations", *jobsetResponse.Keepnr)
-	d.Set("visible", *jobsetResponse.Visible)
-	d.Set("email_notifications", *jobsetResponse.Enableemail)
-
-	if jobsetResponse.Emailoverride != nil && *jobsetResponse.Emailoverride != "" {
-		d.Set("email_override", *jobsetResponse.Emailoverride)
-	} else {
-		d.Set("email_override", nil)
+	if err != nil {
+		return unexpectedResponse(errsummary, "Expected valid response from existing jobset", err)
 	}
 
-	if jobsetResponse.Flake != nil && *jobsetResponse.Flake != "" {
-		d.Set("flake_uri", *jobsetResponse.Flake)
-	} else {
-		d.Set("flake_uri", nil)
+	state, err := jobsetState(project, jobset, jobsetResponse, sensitiveInputs(d))
+	if err != nil {
+		return malformedResponse(errsummary, "jobset", err)
 	}
-
-	if (jobsetResponse.Nixexprinput != nil && *jobsetResponse.Nixexprinput != "") &&
-		(jobsetResponse.Nixexprpath != nil && *jobsetResponse.Nixexprpath != "") {
-		nixExpression := schema.NewSet(schema.HashResource(nixExprSchema()), []interface{}{
-			map[string]interface{}{
-				"input": *jobsetResponse.Nixexprinput,
-				"file":  *jobsetResponse.Nixexprpath,
-			},
-		})
-
-		d.Set("nix_expression", nixExpression)
-	} else {
-		d.Set("nix_expression", nil)
+	if diags := setState(d, state); diags != nil {
+		return diags
 	}
 
-	if jobsetResponse.Inputs != nil {
-		inputs := schema.NewSet(schema.HashResource(inputSchema()), flattenInputs(*jobsetResponse.Inputs))
-
-		d.Set("input", inputs)
-	} else {
-		d.Set("input", nil)
+	if evaluationWaitEnabled(d.Get("wait_for_evaluation").([]interface{})) {
+		latest, err := latestEvaluationID(ctx, client, project, jobset)
+		if err != nil {
+			return unexpectedResponse(errsummary, "Expected valid response from the jobset's evaluations", err)
+		}
+		if err := d.Set("latest_evaluation_id", latest); err != nil {
+			return diag.FromErr(err)
+		}
 	}
 
-	newID := fmt.Sprintf("%s/%s", *jobsetResponse.Project, *jobsetResponse.Name)
-	d.SetId(newID)
+	d.SetId(fmt.Sprintf("%s/%s", state["project"], state["name"]))
 
 	return nil
 }
 
 func resourceHydraJobsetUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	errsummary := "Failed to update Jobset"
-	client := m.(*api.ClientWithResponses)
+	meta := m.(*providerMeta)
+	if diags := meta.requireWrite(errsummary); diags != nil {
+		return diags
+	}
+	client := meta.client
+	ctx = meta.logContext(ctx, sensitiveInputValues(d)...)
 
 	id := d.Id()
 	newProject := d.Get("project").(string)
@@ -537,20 +700,28 @@ func resourceHydraJobsetUpdate(ctx context.Context, d *schema.ResourceData, m in
 		return diags
 	}
 
-	put, err := client.PutJobsetProjectIdJobsetIdWithResponse(ctx, curProject, curJobset, *body)
-	if err != nil {
-		return diag.FromErr(err)
+	tflog.SubsystemInfo(ctx, subsystemJobset, "Updating jobset", map[string]interface{}{
+		"project":     curProject,
+		"jobset":      curJobset,
+		"new_project": newProject,
+		"new_name":    newJobset,
+	})
+
+	// Remember the latest evaluation, to tell the one the change leads to
+	// apart from it. Changing how to wait doesn't change the jobset.
+	wait := d.HasChangeExcept("wait_for_evaluation") && evaluationWaitEnabled(d.Get("wait_for_evaluation").([]interface{}))
+	var previous int
+	if wait {
+		if previous, err = latestEvaluationID(ctx, client, curProject, curJobset); err != nil {
+			return unexpectedResponse(errsummary, "Expected valid response from the jobset's evaluations", err)
+		}
 	}
-	defer put.HTTPResponse.Body.Close()
+	since := time.Now().Truncate(time.Second)
 
 	// If we didn't get the expected response, show what went wrong
-	if put.JSON200 == nil {
-		return []diag.Diagnostic{{
-			Severity: diag.Error,
-			Summary:  errsummary,
-			Detail: fmt.Sprintf("Expected valid reponse from existing jobset, got %s:\n    %s",
-				put.Status(), string(put.Body)),
-		}}
+	if err := client.UpdateJobset(ctx, curProject, curJobset, *body); err != nil {
+		return unexpectedResponse(errsummary, "Expected valid reponse from existing jobset", err,
+			jobsetErrorAttributes...)
 	}
 
 	if d.HasChange("name") || d.HasChange("project") {
@@ -558,13 +729,25 @@ func resourceHydraJobsetUpdate(ctx context.Context, d *schema.ResourceData, m in
 		d.SetId(id)
 	}
 
+	if wait {
+		diags = awaitJobsetEvaluation(ctx, d, client, newProject, newJobset, previous, since, errsummary)
+		if diags.HasError() {
+			return diags
+		}
+	}
+
 	// Ensure we can still read the Jobset
-	return resourceHydraJobsetRead(ctx, d, m)
+	return append(diags, resourceHydraJobsetRead(ctx, d, m)...)
 }
 
 func resourceHydraJobsetDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	errsummary := "Failed to delete Jobset"
-	client := m.(*api.ClientWithResponses)
+	meta := m.(*providerMeta)
+	if diags := meta.requireWrite(errsummary); diags != nil {
+		return diags
+	}
+	client := meta.client
+	ctx = meta.logContext(ctx, sensitiveInputValues(d)...)
 
 	id := d.Id()
 
@@ -573,39 +756,190 @@ func resourceHydraJobsetDelete(ctx context.Context, d *schema.ResourceData, m in
 		return diag.FromErr(err)
 	}
 
-	del, err := client.DeleteJobsetProjectIdJobsetIdWithResponse(ctx, project, jobset)
+	tflog.SubsystemInfo(ctx, subsystemJobset, "Deleting jobset", map[string]interface{}{
+		"project": project,
+		"jobset":  jobset,
+	})
+
+	// Check to make sure the jobset was actually deleted
+	if err := client.DeleteJobset(ctx, project, jobset); err != nil && !isNotFound(err) {
+		return unexpectedResponse(errsummary, "Expected valid jobset deletion response", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// evaluationWaitEnabled reports whether the wait_for_evaluation block is set
+// and enabled.
+func evaluationWaitEnabled(blocks []interface{}) bool {
+	if len(blocks) == 0 || blocks[0] == nil {
+		return false
+	}
+	return blocks[0].(map[string]interface{})["enabled"].(bool)
+}
+
+// latestEvaluationID returns the ID of the jobset's latest evaluation, or 0 if
+// it has none.
+func latestEvaluationID(ctx context.Context, client *hydraclient.Client, project, jobset string) (int, error) {
+	eval, err := client.LatestEvaluation(ctx, project, jobset)
 	if err != nil {
+		return 0, err
+	}
+	if eval == nil {
+		return 0, nil
+	}
+	return intOr(eval.Id, 0), nil
+}
+
+// awaitJobsetEvaluation waits for the evaluation that follows the jobset being
+// written since the given time, if wait_for_evaluation asks for it, and records
+// it in latest_evaluation_id. previous is the ID of the latest evaluation
+// before the write.
+func awaitJobsetEvaluation(ctx context.Context, d *schema.ResourceData, client *hydraclient.Client, project, jobset string, previous int, since time.Time, errsummary string) diag.Diagnostics {
+	blocks := d.Get("wait_for_evaluation").([]interface{})
+	if !evaluationWaitEnabled(blocks) {
+		return nil
+	}
+	if d.Get("state").(string) == "disabled" {
+		tflog.SubsystemInfo(ctx, subsystemJobset, "Not waiting for the evaluation of a disabled jobset", map[string]interface{}{
+			"project": project,
+			"jobset":  jobset,
+		})
+		return nil
+	}
+
+	wait := blocks[0].(map[string]interface{})
+	// The timeout was validated with the configuration.
+	timeout, _ := time.ParseDuration(wait["timeout"].(string))
+
+	tflog.SubsystemInfo(ctx, subsystemJobset, "Waiting for the jobset to be evaluated", map[string]interface{}{
+		"project": project,
+		"jobset":  jobset,
+		"timeout": timeout.String(),
+	})
+
+	latest, err := waitForEvaluation(ctx, client, project, jobset, previous, since, timeout)
+	if err := d.Set("latest_evaluation_id", latest); err != nil {
 		return diag.FromErr(err)
 	}
-	defer del.HTTPResponse.Body.Close()
 
-	// Check to make sure the project was actually deleted
-	if del.HTTPResponse.StatusCode != http.StatusOK {
-		return []diag.Diagnostic{{
+	var evalErr *evaluationError
+	switch {
+	case errors.As(err, &evalErr) && wait["fail_on_eval_error"].(bool):
+		return diag.Diagnostics{{
 			Severity: diag.Error,
 			Summary:  errsummary,
-			Detail: fmt.Sprintf("Expected valid project deletion response, got %s:\n    %s",
-				del.Status(), string(del.Body)),
+			Detail:   fmt.Sprintf("Hydra failed to evaluate the jobset: %s", evalErr.message),
+		}}
+	case errors.As(err, &evalErr):
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Jobset evaluation failed",
+			Detail:   fmt.Sprintf("Hydra failed to evaluate the jobset: %s", evalErr.message),
+		}}
+	case err != nil:
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  errsummary,
+			Detail:   fmt.Sprintf("The jobset was saved, but waiting for Hydra to evaluate it failed: %s", err),
 		}}
 	}
 
-	d.SetId("")
-
 	return nil
 }
 
-func flattenInputs(in map[string]api.JobsetInput) []interface{} {
-	out := make([]interface{}, 0, len(in))
+// evaluationError is Hydra's error evaluating a jobset, or fetching its
+// inputs.
+type evaluationError struct {
+	message string
+}
+
+func (e *evaluationError) Error() string {
+	return e.message
+}
+
+// waitForEvaluation waits until the jobset has an evaluation newer than
+// previous, or the evaluator checked it since the given time, and returns the
+// ID of the latest evaluation. If the evaluation failed, the error is an
+// *evaluationError. A check that finds nothing changed doesn't lead to a new
+// evaluation, in which case the previous one is returned.
+func waitForEvaluation(ctx context.Context, client *hydraclient.Client, project, jobset string, previous int, since time.Time, timeout time.Duration) (int, error) {
+	latest := previous
+
+	err := retry.RetryContext(ctx, timeout, func() *retry.RetryError {
+		// The jobset is fetched first, so that an evaluation which finished
+		// by the time it was checked is among the evaluations.
+		j, err := client.FetchJobset(ctx, project, jobset)
+		if err != nil {
+			return retry.NonRetryableError(err)
+		}
+
+		id, err := latestEvaluationID(ctx, client, project, jobset)
+		if err != nil {
+			return retry.NonRetryableError(err)
+		}
+		if id > latest {
+			latest = id
+		}
+		if latest > previous {
+			return nil
+		}
+
+		if msg := stringOr(j.Errormsg, ""); msg != "" && int64(intOr(j.Errortime, 0)) >= since.Unix() {
+			return retry.NonRetryableError(&evaluationError{message: msg})
+		}
 
-	for k := range in {
-		props := make(map[string]interface{})
+		if int64(intOr(j.Lastcheckedtime, 0)) >= since.Unix() {
+			if msg := stringOr(j.Fetcherrormsg, ""); msg != "" {
+				return retry.NonRetryableError(&evaluationError{message: msg})
+			}
+			return nil
+		}
 
-		props["name"] = *in[k].Name
-		props["notify_committers"] = *in[k].Emailresponsible
-		props["type"] = *in[k].Type
-		props["value"] = *in[k].Value
-		out = append(out, props)
+		tflog.SubsystemDebug(ctx, subsystemJobset, "Waiting for a new evaluation", map[string]interface{}{
+			"project":             project,
+			"jobset":              jobset,
+			"previous_evaluation": previous,
+		})
+		return retry.RetryableError(fmt.Errorf("jobset %s:%s not evaluated yet", project, jobset))
+	})
+
+	return latest, err
+}
+
+// sensitiveInputs returns the names of the inputs that are marked as
+// sensitive, as Hydra doesn't know about this.
+func sensitiveInputs(d *schema.ResourceData) map[string]bool {
+	names := make(map[string]bool)
+	for _, value := range d.Get("input").(*schema.Set).List() {
+		v := value.(map[string]interface{})
+		if v["sensitive"].(bool) {
+			names[v["name"].(string)] = true
+		}
+	}
+	return names
+}
+
+// sensitiveInputValues returns the values of the sensitive inputs, both before
+// and after the change being applied, so that they can be masked in the logs.
+func sensitiveInputValues(d *schema.ResourceData) []string {
+	var values []string
+
+	old, new := d.GetChange("input")
+	for _, inputs := range []interface{}{old, new} {
+		set, ok := inputs.(*schema.Set)
+		if !ok {
+			continue
+		}
+		for _, value := range set.List() {
+			v := value.(map[string]interface{})
+			if v["sensitive"].(bool) {
+				values = append(values, v["value"].(string))
+			}
+		}
 	}
 
-	return out
+	return values
 }
@@ -4,15 +4,19 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-
-	"terraform-provider-hydra/hydra/api"
 )
 
 func TestAccHydraJobset_basic(t *testing.T) {
@@ -233,7 +237,7 @@ func TestAccHydraJobset_legacyToFlakeAndBack(t *testing.T) {
 
 // testAccCheckExampleResourceDestroy verifies the Jobset has been destroyed
 func testAccCheckHydraJobsetDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*api.ClientWithResponses)
+	client := testAccProvider.Meta().(*providerMeta).client
 	ctx := context.Background()
 
 	for _, rs := range s.RootModule().Resources {
@@ -276,7 +280,7 @@ func testAccCheckJobsetExists(name string) resource.TestCheckFunc {
 			return fmt.Errorf("No project is set for %s", name)
 		}
 
-		client := testAccProvider.Meta().(*api.ClientWithResponses)
+		client := testAccProvider.Meta().(*providerMeta).client
 		ctx := context.Background()
 
 		get, err := client.GetJobsetProjectIdJobsetIdWithResponse(ctx, projectID, jobsetID)
@@ -310,7 +314,7 @@ func testAccCheckJobsetInputsChanged(name string, inputName1 string, inputName2
 			return fmt.Errorf("No project is set for %s", name)
 		}
 
-		client := testAccProvider.Meta().(*api.ClientWithResponses)
+		client := testAccProvider.Meta().(*providerMeta).client
 		ctx := context.Background()
 
 		get, err := client.GetJobsetProjectIdJobsetIdWithResponse(ctx, projectID, jobsetID)
@@ -352,7 +356,7 @@ func testAccCheckJobsetType(name string, jobsetType int) resource.TestCheckFunc
 			return fmt.Errorf("No project is set for %s", name)
 		}
 
-		client := testAccProvider.Meta().(*api.ClientWithResponses)
+		client := testAccProvider.Meta().(*providerMeta).client
 		ctx := context.Background()
 
 		get, err := client.GetJobsetProjectIdJobsetIdWithResponse(ctx, projectID, jobsetID)
@@ -399,7 +403,7 @@ func testAccCheckJobsetChangeNixExprFile(name string) resource.TestCheckFunc {
 			return fmt.Errorf("No project is set for %s", name)
 		}
 
-		client := testAccProvider.Meta().(*api.ClientWithResponses)
+		client := testAccProvider.Meta().(*providerMeta).client
 		ctx := context.Background()
 
 		get, err := client.GetJobsetProjectIdJobsetIdWithResponse(ctx, projectID, jobsetID)
@@ -823,3 +827,179 @@ resource "hydra_jobset" "test" {
   }
 }`, project, os.Getenv("HYDRA_USERNAME"), jobset)
 }
+
+func TestResourceHydraJobsetRead_malformed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"enabled": 7, "inputs": {"nixpkgs": {"value": "https://github.com/NixOS/nixpkgs"}}}`)
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, resourceHydraJobset().Schema, map[string]interface{}{})
+	d.SetId("nixpkgs/trunk")
+
+	diags := resourceHydraJobsetRead(context.Background(), d, testMeta(t, server.URL))
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "malformed jobset") {
+		t.Fatalf("expected the malformed jobset to be reported, got %+v", diags)
+	}
+	if !strings.Contains(diags[0].Detail, "unknown state 7") || !strings.Contains(diags[0].Detail, `input "nixpkgs" has no type`) {
+		t.Errorf("expected every problem to be reported, got %q", diags[0].Detail)
+	}
+}
+
+func TestResourceHydraJobsetDelete_notFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": "Jobset nixpkgs:trunk doesn't exist."}`)
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, resourceHydraJobset().Schema, map[string]interface{}{})
+	d.SetId("nixpkgs/trunk")
+
+	if diags := resourceHydraJobsetDelete(context.Background(), d, testMeta(t, server.URL)); diags.HasError() {
+		t.Fatalf("expected a jobset deleted outside of Terraform to be destroyed, got %+v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("expected the jobset to be removed from the state, got ID %q", d.Id())
+	}
+}
+
+func TestCheckProjectAllowsDynamicRunCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/project/allowed":
+			fmt.Fprint(w, `{"name": "allowed", "enable_dynamic_run_command": true}`)
+		case "/project/disallowed":
+			fmt.Fprint(w, `{"name": "disallowed", "enable_dynamic_run_command": false}`)
+		case "/project/old":
+			fmt.Fprint(w, `{"name": "old"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": "Project doesn't exist."}`)
+		}
+	}))
+	defer server.Close()
+
+	meta := testMeta(t, server.URL)
+
+	cases := map[string]bool{
+		"allowed":    false,
+		"disallowed": true,
+		"old":        true,
+		// Created in the same apply, presumably.
+		"missing": false,
+	}
+	for project, fails := range cases {
+		err := checkProjectAllowsDynamicRunCommand(context.Background(), meta, project)
+		if (err != nil) != fails {
+			t.Errorf("%s: unexpected error %v", project, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "doesn't allow dynamic RunCommand") {
+			t.Errorf("%s: unexpected error %q", project, err)
+		}
+	}
+}
+
+// evalHydra evaluates the jobset nixpkgs/trunk on the second time it's
+// fetched, either successfully or with errormsg.
+type evalHydra struct {
+	mu       sync.Mutex
+	errormsg string
+	fetches  int
+}
+
+func (h *evalHydra) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	evaluated := h.fetches > 1
+
+	switch r.URL.Path {
+	case "/jobset/nixpkgs/trunk":
+		h.fetches++
+		if evaluated && h.errormsg != "" {
+			now := time.Now().Unix()
+			fmt.Fprintf(w, `{"name": "trunk", "errormsg": %q, "errortime": %d, "lastcheckedtime": %d}`, h.errormsg, now, now)
+			return
+		}
+		fmt.Fprint(w, `{"name": "trunk", "lastcheckedtime": 1}`)
+	case "/jobset/nixpkgs/trunk/evals":
+		if evaluated && h.errormsg == "" {
+			fmt.Fprint(w, `{"evals": [{"id": 8}, {"id": 7}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"evals": [{"id": 7}]}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": "Not found."}`)
+	}
+}
+
+func TestAwaitJobsetEvaluation(t *testing.T) {
+	cases := []struct {
+		name            string
+		errormsg        string
+		failOnEvalError bool
+		severity        diag.Severity
+		latest          int
+	}{
+		{name: "evaluated", latest: 8},
+		{name: "eval error", errormsg: "error: undefined variable 'pkgs'", latest: 7, severity: diag.Warning},
+		{name: "fail on eval error", errormsg: "error: undefined variable 'pkgs'", failOnEvalError: true, latest: 7, severity: diag.Error},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			server := httptest.NewServer(&evalHydra{errormsg: c.errormsg})
+			defer server.Close()
+
+			d := schema.TestResourceDataRaw(t, resourceHydraJobset().Schema, map[string]interface{}{
+				"state": "enabled",
+				"wait_for_evaluation": []interface{}{map[string]interface{}{
+					"enabled":            true,
+					"timeout":            "1m",
+					"fail_on_eval_error": c.failOnEvalError,
+				}},
+			})
+			client := testMeta(t, server.URL).client
+
+			diags := awaitJobsetEvaluation(context.Background(), d, client, "nixpkgs", "trunk", 7, time.Now().Truncate(time.Second), "Failed to create jobset")
+			if c.errormsg == "" && len(diags) > 0 {
+				t.Fatalf("unexpected diagnostics: %+v", diags)
+			}
+			if c.errormsg != "" {
+				if len(diags) != 1 || diags[0].Severity != c.severity || !strings.Contains(diags[0].Detail, c.errormsg) {
+					t.Fatalf("expected Hydra's error, got %+v", diags)
+				}
+			}
+
+			if got := d.Get("latest_evaluation_id").(int); got != c.latest {
+				t.Errorf("expected latest_evaluation_id %d, got %d", c.latest, got)
+			}
+		})
+	}
+}
+
+func TestAwaitJobsetEvaluation_disabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL)
+	}))
+	defer server.Close()
+	client := testMeta(t, server.URL).client
+
+	configs := []map[string]interface{}{
+		{"state": "enabled"},
+		{"state": "enabled", "wait_for_evaluation": []interface{}{map[string]interface{}{"enabled": false}}},
+		{"state": "disabled", "wait_for_evaluation": []interface{}{map[string]interface{}{"enabled": true}}},
+	}
+	for _, config := range configs {
+		d := schema.TestResourceDataRaw(t, resourceHydraJobset().Schema, config)
+		if diags := awaitJobsetEvaluation(context.Background(), d, client, "nixpkgs", "trunk", 0, time.Now(), "Failed to create jobset"); diags != nil {
+			t.Errorf("%v: unexpected diagnostics %+v", config, diags)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package hydra
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.opentelemetry.io/otel/attribute"
+
+	hydraclient "terraform-provider-hydra/hydra/client"
+)
+
+func resourceHydraJobsetTrigger() *schema.Resource {
+	return &schema.Resource{
+		Description: "Resource that has Hydra evaluate jobsets right away, rather than when their `check_interval` next comes around. " +
+			"The jobsets are triggered when the resource is created, and again whenever it's replaced, e.g. because `triggers` changed.",
+
+		CreateContext: traced("hydra_jobset_trigger.create", jobsetTriggerSpanAttributes, resourceHydraJobsetTriggerCreate),
+		ReadContext:   schema.NoopContext,
+		UpdateContext: schema.NoopContext,
+		DeleteContext: schema.NoopContext,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(15 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"jobsets": {
+				Description: "The jobsets to trigger, each as `<project>:<jobset>`.",
+				Type:        schema.TypeList,
+				Required:    true,
+				ForceNew:    true,
+				MinItems:    1,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+					ValidateFunc: validation.StringMatch(regexp.MustCompile(`^[^:]+:[^:]+$`),
+						"must be formatted as <project>:<jobset>"),
+				},
+			},
+			"triggers": {
+				Description: "Arbitrary values that trigger the jobsets again whenever they change, e.g. the `id` of a `hydra_jobset` and the commit to evaluate.",
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"wait_for_check": {
+				Description: "Whether or not to wait until the evaluator has checked every triggered jobset, i.e. until their `last_checked_time` is past `triggered_at`. This doesn't wait for the builds.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"jobsets_triggered": {
+				Description: "The jobsets Hydra triggered, as reported by Hydra. Disabled jobsets and those that don't exist aren't triggered.",
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+			},
+			"triggered_at": {
+				Description: "When the jobsets were triggered (RFC 3339).",
+				Type:        schema.TypeString,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+// jobsetTriggerSpanAttributes identifies the jobsets in the spans of the
+// trigger's operations.
+func jobsetTriggerSpanAttributes(d *schema.ResourceData) []attribute.KeyValue {
+	var jobsets []string
+	for _, jobset := range d.Get("jobsets").([]interface{}) {
+		jobsets = append(jobsets, jobset.(string))
+	}
+
+	return []attribute.KeyValue{attribute.StringSlice("hydra.jobsets", jobsets)}
+}
+
+func resourceHydraJobsetTriggerCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	errsummary := "Failed to trigger jobsets"
+	meta := m.(*providerMeta)
+	if diags := meta.requireWrite(errsummary); diags != nil {
+		return diags
+	}
+	client := meta.client
+	ctx = meta.logContext(ctx)
+
+	var jobsets []string
+	for _, jobset := range d.Get("jobsets").([]interface{}) {
+		jobsets = append(jobsets, jobset.(string))
+	}
+	tflog.SubsystemInfo(ctx, subsystemJobset, "Triggering jobsets", map[string]interface{}{
+		"jobsets": jobsets,
+	})
+
+	// Hydra's timestamps are in seconds.
+	triggeredAt := time.Now().Truncate(time.Second)
+
+	triggered, err := client.TriggerJobsets(ctx, jobsets)
+	if err != nil {
+		return unexpectedResponse(errsummary, "Expected valid response when triggering jobsets", err)
+	}
+
+	d.SetId(id.UniqueId())
+	if err := d.Set("jobsets_triggered", triggered); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("triggered_at", triggeredAt.UTC().Format(time.RFC3339)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var diags diag.Diagnostics
+	if missed := untriggeredJobsets(jobsets, triggered); len(missed) > 0 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Some jobsets weren't triggered",
+			Detail: fmt.Sprintf("Hydra didn't trigger %s. Jobsets that are disabled or don't exist can't be triggered.",
+				strings.Join(missed, ", ")),
+		})
+	}
+
+	if d.Get("wait_for_check").(bool) {
+		if err := waitForJobsetChecks(ctx, client, triggered, triggeredAt, d.Timeout(schema.TimeoutCreate)); err != nil {
+			return append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  errsummary,
+				Detail:   fmt.Sprintf("The jobsets were triggered, but waiting for Hydra to check them failed: %s", err),
+			})
+		}
+	}
+
+	return diags
+}
+
+// untriggeredJobsets returns the jobsets that were asked to be triggered, but
+// weren't.
+func untriggeredJobsets(jobsets, triggered []string) []string {
+	done := make(map[string]bool, len(triggered))
+	for _, jobset := range triggered {
+		done[jobset] = true
+	}
+
+	var missed []string
+	for _, jobset := range jobsets {
+		if !done[jobset] {
+			missed = append(missed, jobset)
+		}
+	}
+	return missed
+}
+
+// waitForJobsetChecks waits until the evaluator has checked each of the
+// jobsets, given as "project:jobset", since the given time.
+func waitForJobsetChecks(ctx context.Context, client *hydraclient.Client, jobsets []string, since time.Time, timeout time.Duration) error {
+	pending := append([]string(nil), jobsets...)
+
+	return retry.RetryContext(ctx, timeout, func() *retry.RetryError {
+		var still []string
+		for _, jobset := range pending {
+			project, name, _ := strings.Cut(jobset, ":")
+
+			j, err := client.FetchJobset(ctx, project, name)
+			if err != nil {
+				return retry.NonRetryableError(err)
+			}
+
+			if int64(intOr(j.Lastcheckedtime, 0)) < since.Unix() {
+				still = append(still, jobset)
+			}
+		}
+		pending = still
+
+		if len(pending) > 0 {
+			tflog.SubsystemDebug(ctx, subsystemJobset, "Waiting for jobsets to be checked", map[string]interface{}{
+				"jobsets": pending,
+			})
+			return retry.RetryableError(fmt.Errorf("%s not checked yet", strings.Join(pending, ", ")))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,89 @@
+package hydra
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// triggerHydra triggers the jobsets it knows, and has them checked on the
+// second time they are fetched after that.
+type triggerHydra struct {
+	mu      sync.Mutex
+	fetches map[string]int
+	pushed  time.Time
+}
+
+func (h *triggerHydra) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodPut && r.URL.Path == "/api/push":
+		h.pushed = time.Now()
+		fmt.Fprint(w, `{"jobsetsTriggered": ["nixpkgs:trunk"]}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/jobset/nixpkgs/trunk":
+		if h.fetches == nil {
+			h.fetches = make(map[string]int)
+		}
+		h.fetches[r.URL.Path]++
+
+		checked := h.pushed.Add(-time.Hour)
+		if h.fetches[r.URL.Path] > 1 {
+			checked = h.pushed.Add(time.Second)
+		}
+		fmt.Fprintf(w, `{"name": "trunk", "lastcheckedtime": %d}`, checked.Unix())
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": "Not found."}`)
+	}
+}
+
+func TestResourceHydraJobsetTriggerCreate(t *testing.T) {
+	fake := &triggerHydra{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, resourceHydraJobsetTrigger().Schema, map[string]interface{}{
+		"jobsets":        []interface{}{"nixpkgs:trunk", "nixpkgs:missing"},
+		"wait_for_check": true,
+	})
+
+	diags := resourceHydraJobsetTriggerCreate(context.Background(), d, testMeta(t, server.URL))
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+	if len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Errorf("expected a warning about the jobset that wasn't triggered, got %+v", diags)
+	}
+
+	if d.Id() == "" {
+		t.Errorf("expected an ID to be set")
+	}
+	if got := d.Get("jobsets_triggered"); !reflect.DeepEqual(got, []interface{}{"nixpkgs:trunk"}) {
+		t.Errorf("unexpected jobsets_triggered %v", got)
+	}
+	if _, err := time.Parse(time.RFC3339, d.Get("triggered_at").(string)); err != nil {
+		t.Errorf("unexpected triggered_at: %s", err)
+	}
+	if fake.fetches["/jobset/nixpkgs/trunk"] != 2 {
+		t.Errorf("expected to poll the jobset until it was checked, got %d fetches", fake.fetches["/jobset/nixpkgs/trunk"])
+	}
+}
+
+func TestUntriggeredJobsets(t *testing.T) {
+	missed := untriggeredJobsets([]string{"nixpkgs:trunk", "nixpkgs:staging", "nixos:release"}, []string{"nixpkgs:staging"})
+	if want := []string{"nixpkgs:trunk", "nixos:release"}; !reflect.DeepEqual(missed, want) {
+		t.Errorf("expected %v, got %v", want, missed)
+	}
+}
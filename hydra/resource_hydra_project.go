@@ -3,10 +3,15 @@ package hydra
 import (
 	"context"
 	"fmt"
-	"net/http"
+	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.opentelemetry.io/otel/attribute"
 
 	"terraform-provider-hydra/hydra/api"
 )
@@ -33,16 +38,26 @@ func declInputSchema() *schema.Resource {
 	}
 }
 
+// The attributes that Hydra's errors about invalid projects refer to, e.g.
+// "Invalid project identifier ‘foo bar’."
+var projectErrorAttributes = []errorAttribute{
+	{regexp.MustCompile(`(?i)project (identifier|name)|project .* already exists|identifier is already taken`), cty.GetAttrPath("name")},
+	{regexp.MustCompile(`(?i)display ?name`), cty.GetAttrPath("display_name")},
+	{regexp.MustCompile(`(?i)owner|user .* (doesn't|does not) exist`), cty.GetAttrPath("owner")},
+	{regexp.MustCompile(`(?i)homepage`), cty.GetAttrPath("homepage")},
+	{regexp.MustCompile(`(?i)declarative|spec file`), cty.GetAttrPath("declarative")},
+}
+
 func resourceHydraProject() *schema.Resource {
 	return &schema.Resource{
 		Description: "Resource defining a Hydra project.",
 
-		CreateContext: resourceHydraProjectCreate,
-		ReadContext:   resourceHydraProjectRead,
-		UpdateContext: resourceHydraProjectUpdate,
-		DeleteContext: resourceHydraProjectDelete,
+		CreateContext: traced("hydra_project.create", projectSpanAttributes, resourceHydraProjectCreate),
+		ReadContext:   traced("hydra_project.read", projectSpanAttributes, resourceHydraProjectRead),
+		UpdateContext: traced("hydra_project.update", projectSpanAttributes, resourceHydraProjectUpdate),
+		DeleteContext: traced("hydra_project.delete", projectSpanAttributes, resourceHydraProjectDelete),
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: resourceHydraProjectImport,
 		},
 
 		Schema: map[string]*schema.Schema{
@@ -84,6 +99,12 @@ func resourceHydraProject() *schema.Resource {
 				Optional:    true,
 				Default:     true,
 			},
+			"enable_dynamic_run_command": {
+				Description: "Whether or not the project's jobsets may run dynamically defined RunCommand hooks. Hydra must also allow dynamic RunCommand in its configuration.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
 			"declarative": {
 				Description: "Configuration of the declarative project.",
 				Type:        schema.TypeSet,
@@ -92,10 +113,26 @@ func resourceHydraProject() *schema.Resource {
 				MaxItems:    1,
 				Elem:        declInputSchema(),
 			},
+			"force_destroy": {
+				Description: "Whether or not to destroy the project even though it has jobsets that Terraform doesn't manage, deleting them and their builds along with it. This is not sent to Hydra, and it has to be applied before the project is destroyed.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
 		},
 	}
 }
 
+// resourceHydraProjectImport imports the project with the given name. Hydra
+// doesn't know about force_destroy, so it gets its default.
+func resourceHydraProjectImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	if err := d.Set("force_destroy", false); err != nil {
+		return nil, err
+	}
+
+	return []*schema.ResourceData{d}, nil
+}
+
 // Construct a PUT request to the /project/{id} endpoint that can either create
 // a new project or update an existing one.
 func createProjectPutBody(project string, d *schema.ResourceData) *api.PutProjectIdJSONRequestBody {
@@ -122,6 +159,11 @@ func createProjectPutBody(project string, d *schema.ResourceData) *api.PutProjec
 		body.Visible = &visible
 	}
 
+	dynamicRunCommand := d.Get("enable_dynamic_run_command").(bool)
+	if dynamicRunCommand {
+		body.EnableDynamicRunCommand = &dynamicRunCommand
+	}
+
 	declarative := d.Get("declarative").(*schema.Set)
 	if len(declarative.List()) > 0 {
 		// There will only ever be one declarative block, so it's fine to access the
@@ -141,44 +183,50 @@ func createProjectPutBody(project string, d *schema.ResourceData) *api.PutProjec
 	return &body
 }
 
+// projectSpanAttributes identifies the project in the spans of its operations.
+func projectSpanAttributes(d *schema.ResourceData) []attribute.KeyValue {
+	project := d.Id()
+	if project == "" {
+		project = d.Get("name").(string)
+	}
+
+	return []attribute.KeyValue{attribute.String("hydra.project", project)}
+}
+
 func resourceHydraProjectCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	errsummary := "Failed to create project"
-	client := m.(*api.ClientWithResponses)
+	meta := m.(*providerMeta)
+	if diags := meta.requireWrite(errsummary); diags != nil {
+		return diags
+	}
+	client := meta.client
+	ctx = meta.logContext(ctx)
 
 	project := d.Get("name").(string)
-
-	get, err := client.GetProjectIdWithResponse(ctx, project)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-	defer get.HTTPResponse.Body.Close()
+	tflog.SubsystemInfo(ctx, subsystemProject, "Creating project", map[string]interface{}{
+		"project": project,
+	})
 
 	// Check to make sure the project doesn't yet exist
-	if get.HTTPResponse.StatusCode != http.StatusNotFound {
+	_, err := client.GetProject(ctx, project)
+	if err == nil {
 		return []diag.Diagnostic{{
 			Severity: diag.Error,
 			Summary:  errsummary,
 			Detail:   "Project already exists.",
 		}}
 	}
+	if !isNotFound(err) {
+		return unexpectedResponse(errsummary, "Expected valid response when checking for an existing project", err)
+	}
 
 	// Now that we're sure the project doesn't exist, we can continue creating it
 	body := createProjectPutBody(project, d)
 
-	put, err := client.PutProjectIdWithResponse(ctx, project, *body)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-	defer put.HTTPResponse.Body.Close()
-
 	// If we didn't get the expected response, show what went wrong
-	if put.JSON201 == nil {
-		return []diag.Diagnostic{{
-			Severity: diag.Error,
-			Summary:  errsummary,
-			Detail: fmt.Sprintf("Expected valid project creation response, got %s:\n    %s",
-				put.Status(), string(put.Body)),
-		}}
+	if err := client.CreateProject(ctx, project, *body); err != nil {
+		return unexpectedResponse(errsummary, "Expected valid project creation response", err,
+			projectErrorAttributes...)
 	}
 
 	d.SetId(project)
@@ -188,89 +236,62 @@ func resourceHydraProjectCreate(ctx context.Context, d *schema.ResourceData, m i
 
 func resourceHydraProjectRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	errsummary := "Failed to read Project"
-	client := m.(*api.ClientWithResponses)
+	meta := m.(*providerMeta)
+	client := meta.client
+	ctx = meta.logContext(ctx)
 
 	id := d.Id()
+	tflog.SubsystemDebug(ctx, subsystemProject, "Reading project", map[string]interface{}{
+		"project": id,
+	})
 
-	get, err := client.GetProjectIdWithResponse(ctx, id)
+	projectResponse, err := client.GetProject(ctx, id)
+	if isNotFound(err) {
+		return removedFromState(d, "Project")
+	}
 	if err != nil {
-		return diag.FromErr(err)
+		return unexpectedResponse(errsummary, "Expected valid response from existing project", err)
 	}
-	defer get.HTTPResponse.Body.Close()
 
-	// Check to make sure the project exists
-	if get.HTTPResponse.StatusCode != http.StatusOK {
-		d.SetId("")
-		return []diag.Diagnostic{{
-			Severity: diag.Error,
-			Summary:  errsummary,
-			Detail: fmt.Sprintf("Expected valid response from existing project, got %s:\n    %s",
-				get.Status(), string(get.Body)),
-		}}
+	state, err := projectState(id, projectResponse)
+	if err != nil {
+		return malformedResponse(errsummary, "project", err)
 	}
-
-	projectResponse := get.JSON200
-
-	d.Set("name", *projectResponse.Name)
-	d.Set("display_name", *projectResponse.Displayname)
-	d.Set("description", *projectResponse.Description)
-	d.Set("homepage", *projectResponse.Homepage)
-	d.Set("owner", *projectResponse.Owner)
-	d.Set("enabled", *projectResponse.Enabled)
-	d.Set("visible", !(*projectResponse.Hidden))
-
-	// If Declarative can be dereferenced and every field is not empty, we update
-	// the internal representation with it. If the project was never declarative,
-	// none of these fields will be set. If it was ever declarative, some of these
-	// fields may be set, and we want to inform Terraform of that.
-	if projectResponse.Declarative != nil &&
-		projectResponse.Declarative.File != nil &&
-		projectResponse.Declarative.Type != nil &&
-		projectResponse.Declarative.Value != nil &&
-		!(*projectResponse.Declarative.File == "" &&
-			*projectResponse.Declarative.Type == "" &&
-			*projectResponse.Declarative.Value == "") {
-		declarative := schema.NewSet(schema.HashResource(declInputSchema()), []interface{}{
-			map[string]interface{}{
-				"file":  *projectResponse.Declarative.File,
-				"type":  *projectResponse.Declarative.Type,
-				"value": *projectResponse.Declarative.Value,
-			},
-		})
-
-		d.Set("declarative", declarative)
-	} else {
-		d.Set("declarative", nil)
+	if diags := setState(d, state); diags != nil {
+		return diags
 	}
 
-	d.SetId(*projectResponse.Name)
+	d.SetId(state["name"].(string))
 
 	return nil
 }
 
 func resourceHydraProjectUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// There's nothing to tell Hydra about force_destroy.
+	if !d.HasChangeExcept("force_destroy") {
+		return nil
+	}
+
 	errsummary := "Failed to update Project"
-	client := m.(*api.ClientWithResponses)
+	meta := m.(*providerMeta)
+	if diags := meta.requireWrite(errsummary); diags != nil {
+		return diags
+	}
+	client := meta.client
+	ctx = meta.logContext(ctx)
 
 	id := d.Id()
 	newProject := d.Get("name").(string)
 	body := createProjectPutBody(newProject, d)
+	tflog.SubsystemInfo(ctx, subsystemProject, "Updating project", map[string]interface{}{
+		"project":  id,
+		"new_name": newProject,
+	})
 
 	// Send the PUT request to the soon-to-be old project name using the resource's ID
-	put, err := client.PutProjectIdWithResponse(ctx, id, *body)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-	defer put.HTTPResponse.Body.Close()
-
-	// If we didn't get the expected response, show what went wrong
-	if put.JSON200 == nil {
-		return []diag.Diagnostic{{
-			Severity: diag.Error,
-			Summary:  errsummary,
-			Detail: fmt.Sprintf("Expected valid response from existing project, got %s:\n    %s",
-				put.Status(), string(put.Body)),
-		}}
+	if err := client.UpdateProject(ctx, id, *body); err != nil {
+		return unexpectedResponse(errsummary, "Expected valid response from existing project", err,
+			projectErrorAttributes...)
 	}
 
 	if d.HasChange("name") {
@@ -283,27 +304,63 @@ func resourceHydraProjectUpdate(ctx context.Context, d *schema.ResourceData, m i
 
 func resourceHydraProjectDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	errsummary := "Failed to delete Project"
-	client := m.(*api.ClientWithResponses)
+	meta := m.(*providerMeta)
+	if diags := meta.requireWrite(errsummary); diags != nil {
+		return diags
+	}
+	client := meta.client
+	ctx = meta.logContext(ctx)
 
 	id := d.Id()
 
-	del, err := client.DeleteProjectIdWithResponse(ctx, id)
-	if err != nil {
-		return diag.FromErr(err)
+	// Jobsets managed by Terraform that depend on the project are destroyed
+	// before it, so whatever jobsets are left aren't managed by Terraform.
+	if !d.Get("force_destroy").(bool) {
+		project, err := client.FetchProject(ctx, id)
+		if isNotFound(err) {
+			// Already deleted outside of Terraform.
+			d.SetId("")
+			return nil
+		}
+		if err != nil {
+			return unexpectedResponse(errsummary, "Expected valid response from existing project", err)
+		}
+		if jobsets := unmanagedJobsets(project); len(jobsets) > 0 {
+			return []diag.Diagnostic{{
+				Severity: diag.Error,
+				Summary:  errsummary,
+				Detail: fmt.Sprintf("Project %q still has jobsets that Terraform doesn't manage: %s. "+
+					"Deleting the project would delete them and their builds too. Delete them first, "+
+					"or set force_destroy = true on the project (and apply that) to delete them with it.",
+					id, strings.Join(jobsets, ", ")),
+			}}
+		}
 	}
-	defer del.HTTPResponse.Body.Close()
+
+	tflog.SubsystemInfo(ctx, subsystemProject, "Deleting project", map[string]interface{}{
+		"project":       id,
+		"force_destroy": d.Get("force_destroy").(bool),
+	})
 
 	// Check to make sure the project was actually deleted
-	if del.HTTPResponse.StatusCode != http.StatusOK {
-		return []diag.Diagnostic{{
-			Severity: diag.Error,
-			Summary:  errsummary,
-			Detail: fmt.Sprintf("Expected valid project deletion response, got %s:\n    %s",
-				del.Status(), string(del.Body)),
-		}}
+	if err := client.DeleteProject(ctx, id); err != nil && !isNotFound(err) {
+		return unexpectedResponse(errsummary, "Expected valid project deletion response", err)
 	}
 
 	d.SetId("")
 
 	return nil
 }
+
+// unmanagedJobsets returns the jobsets of the project that deleting it would
+// delete too, sorted. This includes the jobsets Hydra makes from the spec of a
+// declarative project (and its ".jobsets" jobset), whose builds go as well.
+func unmanagedJobsets(project *api.Project) []string {
+	if project == nil || project.Jobsets == nil {
+		return nil
+	}
+
+	jobsets := append([]string(nil), *project.Jobsets...)
+	sort.Strings(jobsets)
+	return jobsets
+}
@@ -4,15 +4,17 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
-
-	"terraform-provider-hydra/hydra/api"
 )
 
 func TestAccHydraProject_basic(t *testing.T) {
@@ -118,7 +120,7 @@ func TestAccHydraProject_declarative(t *testing.T) {
 
 // testAccCheckExampleResourceDestroy verifies the Project has been destroyed
 func testAccCheckHydraProjectDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*api.ClientWithResponses)
+	client := testAccProvider.Meta().(*providerMeta).client
 	ctx := context.Background()
 
 	for _, rs := range s.RootModule().Resources {
@@ -156,7 +158,7 @@ func testAccCheckProjectExists(name string) resource.TestCheckFunc {
 			return fmt.Errorf("No ID is set for %s", name)
 		}
 
-		client := testAccProvider.Meta().(*api.ClientWithResponses)
+		client := testAccProvider.Meta().(*providerMeta).client
 		ctx := context.Background()
 
 		get, err := client.GetProjectIdWithResponse(ctx, projectID)
@@ -186,7 +188,7 @@ func testAccCheckProjectChangeDeclFile(name string) resource.TestCheckFunc {
 			return fmt.Errorf("No ID is set for %s", name)
 		}
 
-		client := testAccProvider.Meta().(*api.ClientWithResponses)
+		client := testAccProvider.Meta().(*providerMeta).client
 		ctx := context.Background()
 
 		get, err := client.GetProjectIdWithResponse(ctx, projectID)
@@ -231,7 +233,7 @@ func testAccCheckProjectChangeDeclFileValue(name string) resource.TestCheckFunc
 			return fmt.Errorf("No ID is set for %s", name)
 		}
 
-		client := testAccProvider.Meta().(*api.ClientWithResponses)
+		client := testAccProvider.Meta().(*providerMeta).client
 		ctx := context.Background()
 
 		get, err := client.GetProjectIdWithResponse(ctx, projectID)
@@ -305,6 +307,9 @@ resource "hydra_project" "test" {
   enabled      = false
   visible      = true
 
+  # Hydra makes the jobsets from the spec.
+  force_destroy = true
+
   declarative {
     file  = "static-declarative-project/declarative.json"
     type  = "git"
@@ -313,3 +318,125 @@ resource "hydra_project" "test" {
 }
 `, name, os.Getenv("HYDRA_USERNAME"))
 }
+
+func TestResourceHydraProjectRead_notFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": "Project nixpkgs doesn't exist."}`)
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, resourceHydraProject().Schema, map[string]interface{}{
+		"name": "nixpkgs",
+	})
+	d.SetId("nixpkgs")
+
+	diags := resourceHydraProjectRead(context.Background(), d, testMeta(t, server.URL))
+	if diags.HasError() {
+		t.Fatalf("expected a project deleted outside of Terraform not to fail the refresh, got %+v", diags)
+	}
+	if len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Errorf("expected a warning, got %+v", diags)
+	}
+	if d.Id() != "" {
+		t.Errorf("expected the project to be removed from the state, got ID %q", d.Id())
+	}
+}
+
+func TestResourceHydraProjectRead_missingFields(t *testing.T) {
+	// Older Hydras leave out e.g. the declarative fields and `hidden`.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"name": "nixpkgs", "displayname": "Nixpkgs", "description": null}`)
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, resourceHydraProject().Schema, map[string]interface{}{})
+	d.SetId("nixpkgs")
+
+	if diags := resourceHydraProjectRead(context.Background(), d, testMeta(t, server.URL)); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+
+	if d.Get("display_name") != "Nixpkgs" || d.Get("description") != "" {
+		t.Errorf("unexpected display_name %q and description %q", d.Get("display_name"), d.Get("description"))
+	}
+	if !d.Get("enabled").(bool) || !d.Get("visible").(bool) {
+		t.Errorf("expected the project to default to enabled and visible")
+	}
+}
+
+func TestResourceHydraProjectDelete(t *testing.T) {
+	cases := []struct {
+		name         string
+		project      string
+		forceDestroy bool
+		deletes      bool
+		listed       string
+	}{
+		{name: "no jobsets", project: `{"name": "nixpkgs", "jobsets": []}`, deletes: true},
+		{name: "unmanaged jobsets", project: `{"name": "nixpkgs", "jobsets": ["trunk", "staging"]}`, listed: "staging, trunk"},
+		{name: "force_destroy", project: `{"name": "nixpkgs", "jobsets": ["trunk", "staging"]}`, forceDestroy: true, deletes: true},
+		{name: "declarative", project: `{"name": "nixpkgs", "jobsets": [".jobsets", "trunk"], "declarative": {"file": "spec.json", "type": "git", "value": "https://github.com/NixOS/nixpkgs"}}`, listed: ".jobsets, trunk"},
+		{name: "declarative with force_destroy", project: `{"name": "nixpkgs", "jobsets": [".jobsets", "trunk"], "declarative": {"file": "spec.json", "type": "git", "value": "https://github.com/NixOS/nixpkgs"}}`, forceDestroy: true, deletes: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			deleted := false
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch r.Method {
+				case http.MethodGet:
+					fmt.Fprint(w, c.project)
+				case http.MethodDelete:
+					deleted = true
+					fmt.Fprint(w, `{"redirect": "/"}`)
+				}
+			}))
+			defer server.Close()
+
+			d := schema.TestResourceDataRaw(t, resourceHydraProject().Schema, map[string]interface{}{
+				"name":          "nixpkgs",
+				"force_destroy": c.forceDestroy,
+			})
+			d.SetId("nixpkgs")
+
+			diags := resourceHydraProjectDelete(context.Background(), d, testMeta(t, server.URL))
+			if deleted != c.deletes {
+				t.Errorf("expected the project to be deleted: %t, got %t", c.deletes, deleted)
+			}
+			if c.deletes && diags.HasError() {
+				t.Errorf("unexpected diagnostics: %+v", diags)
+			}
+			if !c.deletes && (!diags.HasError() || !strings.Contains(diags[0].Detail, c.listed)) {
+				t.Errorf("expected the unmanaged jobsets to be listed, got %+v", diags)
+			}
+		})
+	}
+}
+
+func TestResourceHydraProjectDelete_notFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error": "Project nixpkgs doesn't exist."}`)
+	}))
+	defer server.Close()
+
+	for _, forceDestroy := range []bool{false, true} {
+		d := schema.TestResourceDataRaw(t, resourceHydraProject().Schema, map[string]interface{}{
+			"name":          "nixpkgs",
+			"force_destroy": forceDestroy,
+		})
+		d.SetId("nixpkgs")
+
+		if diags := resourceHydraProjectDelete(context.Background(), d, testMeta(t, server.URL)); diags.HasError() {
+			t.Fatalf("force_destroy %t: expected a project deleted outside of Terraform to be destroyed, got %+v", forceDestroy, diags)
+		}
+		if d.Id() != "" {
+			t.Errorf("force_destroy %t: expected the project to be removed from the state, got ID %q", forceDestroy, d.Id())
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package hydra
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"go.opentelemetry.io/otel/attribute"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+// The roles a Hydra user can have.
+var userRoles = []string{
+	"admin",
+	"create-projects",
+	"restart-jobs",
+	"bump-to-front",
+	"cancel-build",
+	"eval-jobset",
+}
+
+// The attributes that Hydra's errors about invalid users refer to, e.g.
+// "Invalid user name ‘foo bar’."
+var userErrorAttributes = []errorAttribute{
+	{regexp.MustCompile(`(?i)user ?name|user .* already exists`), cty.GetAttrPath("username")},
+	{regexp.MustCompile(`(?i)full ?name`), cty.GetAttrPath("full_name")},
+	{regexp.MustCompile(`(?i)e-?mail`), cty.GetAttrPath("email_address")},
+	{regexp.MustCompile(`(?i)password`), cty.GetAttrPath("password_wo")},
+	{regexp.MustCompile(`(?i)role`), cty.GetAttrPath("roles")},
+}
+
+func resourceHydraUser() *schema.Resource {
+	return &schema.Resource{
+		Description: "Resource defining a Hydra user. Managing users requires the provider's user to be an admin.",
+
+		CreateContext: traced("hydra_user.create", userSpanAttributes, resourceHydraUserCreate),
+		ReadContext:   traced("hydra_user.read", userSpanAttributes, resourceHydraUserRead),
+		UpdateContext: traced("hydra_user.update", userSpanAttributes, resourceHydraUserUpdate),
+		DeleteContext: traced("hydra_user.delete", userSpanAttributes, resourceHydraUserDelete),
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"username": {
+				Description: "Name of the user. Hydra can't rename users, so changing it replaces the user.",
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+			},
+			"full_name": {
+				Description: "Full name of the user, which Hydra requires.",
+				Type:        schema.TypeString,
+				Required:    true,
+			},
+			"email_address": {
+				Description: "Email address of the user.",
+				Type:        schema.TypeString,
+				Optional:    true,
+			},
+			"password_wo": {
+				Description: "Password of the user, which Hydra requires (of at least 6 characters) when creating the user. It's sent to Hydra when the user is created and whenever `password_wo_version` changes, but never stored in the state. Requires Terraform 1.11 or later.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				WriteOnly:   true,
+			},
+			"password_wo_version": {
+				Description: "Change this to have the password in `password_wo` sent to Hydra again.",
+				Type:        schema.TypeInt,
+				Optional:    true,
+			},
+			"email_only": {
+				Description: "Whether the user only receives email notifications and can't log in.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+			},
+			"roles": {
+				Description: "Roles of the user: `admin`, `create-projects`, `restart-jobs`, `bump-to-front`, `cancel-build` and `eval-jobset`.",
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(userRoles, false),
+				},
+			},
+		},
+	}
+}
+
+// userPassword returns the password in the configuration, which isn't in the
+// plan or the state, or "" if there is none.
+func userPassword(d *schema.ResourceData) string {
+	config := d.GetRawConfig()
+	if config.IsNull() || !config.IsKnown() {
+		return ""
+	}
+
+	password := config.GetAttr("password_wo")
+	if password.IsNull() || !password.IsKnown() {
+		return ""
+	}
+	return password.AsString()
+}
+
+// Construct the body of the PUT requests that create a user (to /register) and
+// update one (to /user/{username}). The password is only sent if given, twice,
+// as Hydra checks that it was typed the same way again.
+func createUserPutBody(d *schema.ResourceData, password string) *api.UserInput {
+	fullName := d.Get("full_name").(string)
+	emailAddress := d.Get("email_address").(string)
+	emailOnly := d.Get("email_only").(bool)
+
+	roles := []string{}
+	for _, role := range d.Get("roles").(*schema.Set).List() {
+		roles = append(roles, role.(string))
+	}
+
+	body := api.UserInput{
+		Fullname:     &fullName,
+		Emailaddress: &emailAddress,
+		Emailonly:    &emailOnly,
+		Roles:        &roles,
+	}
+
+	if password != "" {
+		body.Password = &password
+		body.Password2 = &password
+	}
+
+	return &body
+}
+
+// userSpanAttributes identifies the user in the spans of its operations.
+func userSpanAttributes(d *schema.ResourceData) []attribute.KeyValue {
+	username := d.Id()
+	if username == "" {
+		username = d.Get("username").(string)
+	}
+
+	return []attribute.KeyValue{attribute.String("hydra.user", username)}
+}
+
+func resourceHydraUserCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	errsummary := "Failed to create user"
+	meta := m.(*providerMeta)
+	if diags := meta.requireWrite(errsummary); diags != nil {
+		return diags
+	}
+	client := meta.client
+	password := userPassword(d)
+	ctx = meta.logContext(ctx, password)
+
+	username := d.Get("username").(string)
+	tflog.SubsystemInfo(ctx, subsystemUser, "Creating user", map[string]interface{}{
+		"username": username,
+	})
+
+	// Check to make sure the user doesn't yet exist
+	_, err := client.GetUser(ctx, username)
+	if err == nil {
+		return []diag.Diagnostic{{
+			Severity: diag.Error,
+			Summary:  errsummary,
+			Detail:   "User already exists.",
+		}}
+	}
+	if !isNotFound(err) {
+		return unexpectedResponse(errsummary, "Expected valid response when checking for an existing user", err)
+	}
+
+	body := createUserPutBody(d, password)
+
+	if err := client.CreateUser(ctx, username, *body); err != nil {
+		return unexpectedResponse(errsummary, "Expected valid user creation response", err,
+			userErrorAttributes...)
+	}
+
+	d.SetId(username)
+
+	return nil
+}
+
+func resourceHydraUserRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	errsummary := "Failed to read User"
+	meta := m.(*providerMeta)
+	client := meta.client
+	ctx = meta.logContext(ctx)
+
+	id := d.Id()
+	tflog.SubsystemDebug(ctx, subsystemUser, "Reading user", map[string]interface{}{
+		"username": id,
+	})
+
+	user, err := client.GetUser(ctx, id)
+	if isNotFound(err) {
+		return removedFromState(d, "User")
+	}
+	if err != nil {
+		return unexpectedResponse(errsummary, "Expected valid response from existing user", err)
+	}
+
+	state, err := userState(id, user)
+	if err != nil {
+		return malformedResponse(errsummary, "user", err)
+	}
+	if diags := setState(d, state); diags != nil {
+		return diags
+	}
+
+	d.SetId(state["username"].(string))
+
+	return nil
+}
+
+func resourceHydraUserUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	errsummary := "Failed to update User"
+	meta := m.(*providerMeta)
+	if diags := meta.requireWrite(errsummary); diags != nil {
+		return diags
+	}
+	client := meta.client
+
+	// The password is only sent again when asked to, as Terraform can't tell
+	// whether it changed.
+	var password string
+	if d.HasChange("password_wo_version") {
+		password = userPassword(d)
+	}
+	ctx = meta.logContext(ctx, password)
+
+	id := d.Id()
+	body := createUserPutBody(d, password)
+	tflog.SubsystemInfo(ctx, subsystemUser, "Updating user", map[string]interface{}{
+		"username":         id,
+		"updates_password": password != "",
+	})
+
+	if err := client.UpdateUser(ctx, id, *body); err != nil {
+		return unexpectedResponse(errsummary, "Expected valid response from existing user", err,
+			userErrorAttributes...)
+	}
+
+	// Ensure we can still read the User
+	return resourceHydraUserRead(ctx, d, m)
+}
+
+func resourceHydraUserDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	errsummary := "Failed to delete User"
+	meta := m.(*providerMeta)
+	if diags := meta.requireWrite(errsummary); diags != nil {
+		return diags
+	}
+	client := meta.client
+	ctx = meta.logContext(ctx)
+
+	id := d.Id()
+	tflog.SubsystemInfo(ctx, subsystemUser, "Deleting user", map[string]interface{}{
+		"username": id,
+	})
+
+	if err := client.DeleteUser(ctx, id); err != nil {
+		return unexpectedResponse(errsummary, "Expected valid user deletion response", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package hydra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+func TestAccHydraUser_basic(t *testing.T) {
+	// identifier must start with a letter
+	name := fmt.Sprintf("u%s", acctest.RandString(7))
+	resourceName := "hydra_user.test"
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckHydraUserDestroy,
+		Steps: []resource.TestStep{
+			// Test creation of user
+			{
+				Config: testAccHydraUserConfig(name, "Alice", `["create-projects"]`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUserExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "roles.#", "1"),
+				),
+			},
+			// Test modification of the user's name and roles
+			{
+				Config: testAccHydraUserConfig(name, "Alice Liddell", `["create-projects", "restart-jobs"]`),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckUserExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "full_name", "Alice Liddell"),
+					resource.TestCheckResourceAttr(resourceName, "roles.#", "2"),
+				),
+			},
+			// Test import of user
+			{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"password_wo_version"},
+			},
+		},
+	})
+}
+
+// testAccCheckHydraUserDestroy verifies the User has been destroyed
+func testAccCheckHydraUserDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*providerMeta).client
+	ctx := context.Background()
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "hydra_user" {
+			continue
+		}
+
+		_, err := client.GetUser(ctx, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Expected user %s to be destroyed", rs.Primary.ID)
+		}
+		if !isNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// testAccCheckUserExists verifies the user was successfully created
+func testAccCheckUserExists(name string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[name]
+		if !ok {
+			return fmt.Errorf("Resource not found for %s", name)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No ID is set for %s", name)
+		}
+
+		client := testAccProvider.Meta().(*providerMeta).client
+		_, err := client.GetUser(context.Background(), rs.Primary.ID)
+		return err
+	}
+}
+
+func testAccHydraUserConfig(name, fullName, roles string) string {
+	return fmt.Sprintf(`
+resource "hydra_user" "test" {
+  username            = "%s"
+  full_name           = "%s"
+  email_address       = "%s@example.com"
+  password_wo         = "correct horse battery staple"
+  password_wo_version = 1
+  roles               = %s
+}
+`, name, fullName, name, roles)
+}
+
+func TestCreateUserPutBody(t *testing.T) {
+	d := schema.TestResourceDataRaw(t, resourceHydraUser().Schema, map[string]interface{}{
+		"username":      "alice",
+		"full_name":     "Alice",
+		"email_address": "alice@example.com",
+		"roles":         []interface{}{"restart-jobs", "admin"},
+	})
+
+	body := createUserPutBody(d, "")
+	if body.Password != nil {
+		t.Errorf("expected no password, got %q", *body.Password)
+	}
+	if *body.Fullname != "Alice" || *body.Emailaddress != "alice@example.com" || *body.Emailonly {
+		t.Errorf("unexpected body %+v", body)
+	}
+
+	roles := append([]string(nil), *body.Roles...)
+	sort.Strings(roles)
+	if want := []string{"admin", "restart-jobs"}; !reflect.DeepEqual(roles, want) {
+		t.Errorf("expected roles %v, got %v", want, roles)
+	}
+
+	if body := createUserPutBody(d, "hunter2"); body.Password == nil || *body.Password != "hunter2" || body.Password2 == nil || *body.Password2 != "hunter2" {
+		t.Errorf("expected the password to be sent twice, got %+v", body)
+	}
+}
+
+func TestResourceHydraUserRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user/alice" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": "User doesn't exist."}`)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.User{
+			Username:     strPtr("alice"),
+			Fullname:     strPtr("Alice"),
+			Emailaddress: strPtr("alice@example.com"),
+			Userroles:    &[]string{"admin"},
+		})
+	}))
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, resourceHydraUser().Schema, map[string]interface{}{})
+	d.SetId("alice")
+
+	if diags := resourceHydraUserRead(context.Background(), d, testMeta(t, server.URL)); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %+v", diags)
+	}
+
+	if d.Get("username") != "alice" || d.Get("full_name") != "Alice" || d.Get("email_address") != "alice@example.com" {
+		t.Errorf("unexpected user %v", d.State().Attributes)
+	}
+	if roles := d.Get("roles").(*schema.Set); roles.Len() != 1 || !roles.Contains("admin") {
+		t.Errorf("expected the admin role, got %v", roles.List())
+	}
+
+	d.SetId("bob")
+	diags := resourceHydraUserRead(context.Background(), d, testMeta(t, server.URL))
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || d.Id() != "" {
+		t.Errorf("expected a user deleted outside of Terraform to be removed from the state, got %+v", diags)
+	}
+}
@@ -0,0 +1,291 @@
+package hydra
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+type contextKey string
+
+// Requests made with this key set in their context bypass the re-login logic of
+// the sessionDoer (e.g. the login request itself).
+const noReloginKey contextKey = "hydra-no-relogin"
+
+// session keeps track of the login of the provider, so that it can log in
+// again when Hydra forgets about it (e.g. after the session expired, or Hydra
+// was restarted).
+type session struct {
+	client   *api.ClientWithResponses
+	creds    *credentials
+	endpoint *endpoint
+
+	// The user that the session belongs to, as reported by Hydra.
+	user *hydraUser
+
+	// The cookie jar holding the session cookie, and the HTTP client that uses
+	// it. Only needed when the session is cached.
+	jar   http.CookieJar
+	doer  api.HttpRequestDoer
+	cache *sessionCache
+
+	// The request editors of the client, which are also applied to the requests
+	// the session makes on its own.
+	editors []api.RequestEditorFn
+
+	mu sync.Mutex
+	// gen is incremented every time the provider logs in again, so that
+	// concurrent requests that failed with the same (stale) session only cause
+	// a single re-login.
+	gen      uint64
+	loginErr error
+}
+
+// start establishes the session, reusing a cached one if it is still valid.
+func (s *session) start(ctx context.Context) error {
+	if s.cache != nil && s.restore(ctx) {
+		return nil
+	}
+
+	return s.login(ctx)
+}
+
+// restore loads the cached session cookie into the cookie jar and reports
+// whether Hydra still accepts it.
+func (s *session) restore(ctx context.Context) bool {
+	fields := map[string]interface{}{
+		"host":     s.endpoint.Base,
+		"username": s.creds.Username,
+	}
+
+	cookies, err := s.cache.load(s.endpoint.Base, s.creds.Username)
+	if err != nil {
+		fields["error"] = err.Error()
+		tflog.Warn(ctx, "Failed to load the cached Hydra session", fields)
+		return false
+	}
+	if len(cookies) == 0 {
+		return false
+	}
+
+	u, err := url.Parse(s.endpoint.Server)
+	if err != nil {
+		return false
+	}
+	s.jar.SetCookies(u, cookies)
+
+	valid, err := s.valid(ctx)
+	if err != nil {
+		fields["error"] = err.Error()
+		tflog.Warn(ctx, "Failed to check the cached Hydra session", fields)
+		return false
+	}
+
+	if !valid {
+		tflog.Info(ctx, "Cached Hydra session has expired", fields)
+		if err := s.cache.remove(s.endpoint.Base, s.creds.Username); err != nil {
+			fields["error"] = err.Error()
+			tflog.Warn(ctx, "Failed to remove the expired Hydra session", fields)
+		}
+		return false
+	}
+
+	tflog.Info(ctx, "Reusing cached Hydra session", fields)
+
+	return true
+}
+
+// valid asks Hydra who we are logged in as, which is a cheap way to find out
+// whether the session cookie in the jar is still good.
+func (s *session) valid(ctx context.Context) (bool, error) {
+	ctx = context.WithValue(ctx, noReloginKey, true)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint.URL("current-user"), nil)
+	if err != nil {
+		return false, err
+	}
+	for _, editor := range s.editors {
+		if err := editor(ctx, req); err != nil {
+			return false, err
+		}
+	}
+
+	resp, err := s.doer.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var user struct {
+		Username  string   `json:"username"`
+		Userroles []string `json:"userroles"`
+	}
+
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&user) != nil {
+		return false, nil
+	}
+
+	if user.Username != s.creds.Username {
+		return false, nil
+	}
+
+	s.user = &hydraUser{
+		Username: user.Username,
+		Roles:    user.Userroles,
+	}
+
+	return true, nil
+}
+
+// originEditor sets the Origin and Referer headers on every request that may
+// change state on the Hydra side, as Hydra's cross-site request forgery
+// protection rejects those that don't come from its own base URL. The Referer
+// is what allows this to work for a Hydra served under a sub-path, as the
+// Origin never contains a path.
+func originEditor(e *endpoint) api.RequestEditorFn {
+	return func(ctx context.Context, req *http.Request) error {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			req.Header.Set("Origin", e.Origin)
+			req.Header.Set("Referer", e.Base)
+		}
+		return nil
+	}
+}
+
+// store saves the session cookie in the session cache. Failing to do so only
+// means the next invocation has to log in again, so it isn't fatal.
+func (s *session) store(ctx context.Context) {
+	u, err := url.Parse(s.endpoint.Server)
+	if err == nil {
+		err = s.cache.save(s.endpoint.Base, s.creds.Username, s.jar.Cookies(u))
+	}
+
+	if err != nil {
+		tflog.Warn(ctx, "Failed to cache the Hydra session", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+// generation returns the number of times the provider has logged in again.
+func (s *session) generation() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.gen
+}
+
+// relogin logs in again, unless another request already did so since the
+// generation seen by the caller.
+func (s *session) relogin(ctx context.Context, seen uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.gen != seen {
+		return s.loginErr
+	}
+
+	tflog.Info(ctx, "Hydra session is no longer valid, logging in again", map[string]interface{}{
+		"username": s.creds.Username,
+	})
+
+	s.loginErr = s.login(ctx)
+	s.gen++
+
+	return s.loginErr
+}
+
+// stale reports whether the session seen by the caller is no longer valid,
+// because the provider logged in again since, or Hydra forgot about it.
+func (s *session) stale(ctx context.Context, seen uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.gen != seen {
+		return true
+	}
+
+	valid, err := s.valid(ctx)
+	if err != nil {
+		tflog.Warn(ctx, "Failed to check the Hydra session", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return false
+	}
+
+	return !valid
+}
+
+// sessionDoer is an api.HttpRequestDoer that notices when a request was
+// rejected because the session is no longer valid, logs in again and replays
+// the request once.
+type sessionDoer struct {
+	next    api.HttpRequestDoer
+	session *session
+}
+
+// Do - Perform the provided HTTP request, logging in again if necessary.
+func (d *sessionDoer) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if ctx.Value(noReloginKey) != nil {
+		return d.next.Do(req)
+	}
+
+	seen := d.session.generation()
+
+	resp, err := d.next.Do(req)
+	if err != nil || !isAuthFailure(resp) {
+		return resp, err
+	}
+
+	// We can only replay requests whose body can be recreated.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	// Hydra also forbids what the user isn't allowed to do, which logging in
+	// again wouldn't change.
+	if resp.StatusCode == http.StatusForbidden && !d.session.stale(ctx, seen) {
+		return resp, nil
+	}
+
+	if err := d.session.relogin(ctx, seen); err != nil {
+		tflog.Warn(ctx, "Failed to log in to Hydra again", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return resp, nil
+	}
+
+	// Consume the response so the connection can be reused.
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	// The HTTP client adds the cookies from its jar to the original request, so
+	// drop them in order to send the new session cookie instead.
+	replay := req.Clone(ctx)
+	replay.Header.Del("Cookie")
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		replay.Body = body
+	}
+
+	return d.next.Do(replay)
+}
+
+// isAuthFailure reports whether Hydra refused the request because we might not
+// (or no longer) be logged in. Hydra answers requests that require a login with
+// 403, just like those that the user isn't allowed to make.
+func isAuthFailure(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden
+}
@@ -0,0 +1,124 @@
+package hydra
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// sessionCache persists Hydra session cookies on disk, so that separate
+// invocations of the provider can reuse a session instead of logging in every
+// time. There is one file per host and username.
+type sessionCache struct {
+	dir string
+}
+
+type sessionCacheEntry struct {
+	Host     string            `json:"host"`
+	Username string            `json:"username"`
+	Cookies  map[string]string `json:"cookies"`
+}
+
+func (c *sessionCache) path(host, username string) string {
+	sum := sha256.Sum256([]byte(host + "\x00" + username))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load returns the cached cookies for the host and username, or nil if there
+// are none.
+func (c *sessionCache) load(host, username string) ([]*http.Cookie, error) {
+	path := c.path(host, username)
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Somebody else might have been able to read (or tamper with) the session.
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("refusing to use %s, as it is accessible by other users (mode %s)", path, info.Mode().Perm())
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry sessionCacheEntry
+	if err := json.Unmarshal(contents, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	if entry.Host != host || entry.Username != username {
+		return nil, nil
+	}
+
+	cookies := make([]*http.Cookie, 0, len(entry.Cookies))
+	for name, value := range entry.Cookies {
+		cookies = append(cookies, &http.Cookie{Name: name, Value: value})
+	}
+
+	return cookies, nil
+}
+
+// save stores the cookies for the host and username in a file only readable by
+// the current user.
+func (c *sessionCache) save(host, username string, cookies []*http.Cookie) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+
+	entry := sessionCacheEntry{
+		Host:     host,
+		Username: username,
+		Cookies:  make(map[string]string, len(cookies)),
+	}
+	for _, cookie := range cookies {
+		entry.Cookies[cookie.Name] = cookie.Value
+	}
+
+	contents, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that concurrent provider processes never
+	// see a partially written file.
+	tmp, err := os.CreateTemp(c.dir, ".session-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if _, err := tmp.Write(contents); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path(host, username))
+}
+
+// remove forgets the cached session for the host and username.
+func (c *sessionCache) remove(host, username string) error {
+	err := os.Remove(c.path(host, username))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,280 @@
+package hydra
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+// Hydra leaves fields out of its JSON when they are null in its database, and
+// older releases don't know about some of them at all. Such fields are mapped
+// to the state as Hydra itself treats them:
+//
+//   - a project without a display name shows its name, and one without
+//     `enabled` or `hidden` is enabled and visible;
+//   - a jobset without `enabled` is enabled, one without `type` (which
+//     predates flakes) is a legacy jobset, and one without `visible` is
+//     visible;
+//   - the check interval, scheduling shares and evaluations to keep default to
+//     those of a new jobset in Hydra;
+//   - any other missing string is empty, and any other missing flag (such as
+//     `enable_dynamic_run_command`, which older releases don't have) false.
+const (
+	defaultJobsetState      = 1 // enabled
+	defaultJobsetType       = 0 // legacy
+	defaultCheckInterval    = 300
+	defaultSchedulingShares = 100
+	defaultKeepEvaluations  = 3
+)
+
+func stringOr(p *string, def string) string {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+func intOr(p *int, def int) int {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+func boolOr(p *bool, def bool) bool {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+// timestamp formats a Unix timestamp from Hydra as RFC 3339, or returns ""
+// for a missing one, which Hydra may also report as 0.
+func timestamp(p *int) string {
+	if p == nil || *p == 0 {
+		return ""
+	}
+	return time.Unix(int64(*p), 0).UTC().Format(time.RFC3339)
+}
+
+// projectState maps a project from Hydra to the attributes of hydra_project.
+// id is the name the project was fetched by.
+func projectState(id string, p *api.Project) (map[string]interface{}, error) {
+	if p == nil {
+		return nil, errors.New("the response is empty")
+	}
+
+	name := stringOr(p.Name, id)
+
+	state := map[string]interface{}{
+		"name":         name,
+		"display_name": stringOr(p.Displayname, name),
+		"description":  stringOr(p.Description, ""),
+		"homepage":     stringOr(p.Homepage, ""),
+		"owner":        stringOr(p.Owner, ""),
+		"enabled":      boolOr(p.Enabled, true),
+		"visible":      !boolOr(p.Hidden, false),
+		"declarative":  nil,
+
+		"enable_dynamic_run_command": boolOr(p.EnableDynamicRunCommand, false),
+	}
+
+	// A project that was never declarative has no declarative fields, and one
+	// that was declarative once may still have some of them, which is reported
+	// for Terraform to remove.
+	if decl := p.Declarative; decl != nil {
+		file := stringOr(decl.File, "")
+		inputType := stringOr(decl.Type, "")
+		value := stringOr(decl.Value, "")
+
+		if file != "" || inputType != "" || value != "" {
+			state["declarative"] = schema.NewSet(schema.HashResource(declInputSchema()), []interface{}{
+				map[string]interface{}{
+					"file":  file,
+					"type":  inputType,
+					"value": value,
+				},
+			})
+		}
+	}
+
+	return state, nil
+}
+
+// jobsetState maps a jobset from Hydra to the attributes of hydra_jobset.
+// project and jobset are the names it was fetched by, and sensitive the names
+// of the inputs marked as sensitive, which Hydra doesn't know about.
+func jobsetState(project, jobset string, j *api.Jobset, sensitive map[string]bool) (map[string]interface{}, error) {
+	if j == nil {
+		return nil, errors.New("the response is empty")
+	}
+
+	var errs []error
+
+	enabled := intOr(j.Enabled, defaultJobsetState)
+	state := stateToString(enabled)
+	if state == "" {
+		errs = append(errs, fmt.Errorf("unknown state %d", enabled))
+	}
+
+	typ := intOr(j.Type, defaultJobsetType)
+	jobsetType := jobsetTypeToString(typ)
+	if jobsetType == "" {
+		errs = append(errs, fmt.Errorf("unknown type %d", typ))
+	}
+
+	attrs := map[string]interface{}{
+		"project":             stringOr(j.Project, project),
+		"name":                stringOr(j.Name, jobset),
+		"state":               state,
+		"type":                jobsetType,
+		"description":         stringOr(j.Description, ""),
+		"check_interval":      intOr(j.Checkinterval, defaultCheckInterval),
+		"scheduling_shares":   intOr(j.Schedulingshares, defaultSchedulingShares),
+		"keep_evaluations":    intOr(j.Keepnr, defaultKeepEvaluations),
+		"visible":             boolOr(j.Visible, true),
+		"email_notifications": boolOr(j.Enableemail, false),
+		"email_override":      nil,
+		"flake_uri":           nil,
+		"nix_expression":      nil,
+		"input":               nil,
+
+		"enable_dynamic_run_command": boolOr(j.EnableDynamicRunCommand, false),
+
+		// The evaluation status, which is only ever read.
+		"error_message":       stringOr(j.Errormsg, ""),
+		"error_time":          timestamp(j.Errortime),
+		"fetch_error_message": stringOr(j.Fetcherrormsg, ""),
+		"last_checked_time":   timestamp(j.Lastcheckedtime),
+		"trigger_time":        timestamp(j.Triggertime),
+		"start_time":          timestamp(j.Starttime),
+	}
+
+	if emailOverride := stringOr(j.Emailoverride, ""); emailOverride != "" {
+		attrs["email_override"] = emailOverride
+	}
+
+	if flake := stringOr(j.Flake, ""); flake != "" {
+		attrs["flake_uri"] = flake
+	}
+
+	input := stringOr(j.Nixexprinput, "")
+	path := stringOr(j.Nixexprpath, "")
+	if input != "" && path != "" {
+		attrs["nix_expression"] = schema.NewSet(schema.HashResource(nixExprSchema()), []interface{}{
+			map[string]interface{}{
+				"input": input,
+				"file":  path,
+			},
+		})
+	}
+
+	if j.Inputs != nil {
+		inputs, err := flattenInputs(*j.Inputs, sensitive)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			attrs["input"] = schema.NewSet(schema.HashResource(inputSchema()), inputs)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return attrs, nil
+}
+
+// flattenInputs maps the inputs of a jobset, keyed by their names, to the
+// `input` blocks of hydra_jobset. Inputs without a name are named by their key,
+// and those without a value have an empty one, but every input needs a type.
+func flattenInputs(in map[string]api.JobsetInput, sensitive map[string]bool) ([]interface{}, error) {
+	names := make([]string, 0, len(in))
+	for k := range in {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	out := make([]interface{}, 0, len(in))
+	var errs []error
+
+	for _, k := range names {
+		input := in[k]
+		name := stringOr(input.Name, k)
+
+		if input.Type == nil || *input.Type == "" {
+			errs = append(errs, fmt.Errorf("input %q has no type", name))
+			continue
+		}
+
+		out = append(out, map[string]interface{}{
+			"name":              name,
+			"type":              *input.Type,
+			"value":             stringOr(input.Value, ""),
+			"notify_committers": boolOr(input.Emailresponsible, false),
+			"sensitive":         sensitive[name],
+		})
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// userState maps a user from Hydra to the attributes of hydra_user. id is the
+// name the user was fetched by.
+func userState(id string, u *api.User) (map[string]interface{}, error) {
+	if u == nil {
+		return nil, errors.New("the response is empty")
+	}
+
+	var roles []interface{}
+	if u.Userroles != nil {
+		for _, role := range *u.Userroles {
+			roles = append(roles, role)
+		}
+	}
+
+	return map[string]interface{}{
+		"username":      stringOr(u.Username, id),
+		"full_name":     stringOr(u.Fullname, ""),
+		"email_address": stringOr(u.Emailaddress, ""),
+		"email_only":    boolOr(u.Emailonly, false),
+		"roles":         schema.NewSet(schema.HashString, roles),
+	}, nil
+}
+
+// setState sets the attributes of the resource.
+func setState(d *schema.ResourceData, state map[string]interface{}) diag.Diagnostics {
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var diags diag.Diagnostics
+	for _, k := range keys {
+		if err := d.Set(k, state[k]); err != nil {
+			diags = append(diags, diag.FromErr(fmt.Errorf("failed to set %s: %w", k, err))...)
+		}
+	}
+	return diags
+}
+
+// malformedResponse builds the diagnostics for an object Hydra returned that
+// can't be mapped to the state.
+func malformedResponse(errsummary, kind string, err error) diag.Diagnostics {
+	return []diag.Diagnostic{{
+		Severity: diag.Error,
+		Summary:  errsummary,
+		Detail:   fmt.Sprintf("Hydra returned a malformed %s: %s", kind, err),
+	}}
+}
@@ -0,0 +1,332 @@
+package hydra
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"terraform-provider-hydra/hydra/api"
+)
+
+func strPtr(s string) *string { return &s }
+func intPtr(i int) *int       { return &i }
+func boolPtr(b bool) *bool    { return &b }
+
+// setList returns the elements of a set attribute, or nil.
+func setList(v interface{}) []interface{} {
+	if set, ok := v.(*schema.Set); ok && set != nil {
+		return set.List()
+	}
+	return nil
+}
+
+func TestProjectState_defaults(t *testing.T) {
+	state, err := projectState("nixpkgs", &api.Project{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := map[string]interface{}{
+		"name":         "nixpkgs",
+		"display_name": "nixpkgs",
+		"description":  "",
+		"homepage":     "",
+		"owner":        "",
+		"enabled":      true,
+		"visible":      true,
+		"declarative":  nil,
+
+		"enable_dynamic_run_command": false,
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("expected %v, got %v", want, state)
+	}
+}
+
+func TestProjectState_fields(t *testing.T) {
+	state, err := projectState("nixpkgs", &api.Project{
+		Name:        strPtr("nixos"),
+		Displayname: strPtr("NixOS"),
+		Description: strPtr("The NixOS distribution"),
+		Homepage:    strPtr("https://nixos.org"),
+		Owner:       strPtr("alice"),
+		Enabled:     boolPtr(false),
+		Hidden:      boolPtr(true),
+
+		EnableDynamicRunCommand: boolPtr(true),
+		Declarative: &api.DeclarativeInput{
+			File:  strPtr("spec.json"),
+			Type:  strPtr("git"),
+			Value: strPtr("https://github.com/NixOS/nixpkgs"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for k, want := range map[string]interface{}{
+		"name":         "nixos",
+		"display_name": "NixOS",
+		"description":  "The NixOS distribution",
+		"homepage":     "https://nixos.org",
+		"owner":        "alice",
+		"enabled":      false,
+		"visible":      false,
+
+		"enable_dynamic_run_command": true,
+	} {
+		if state[k] != want {
+			t.Errorf("expected %s to be %v, got %v", k, want, state[k])
+		}
+	}
+
+	decl := setList(state["declarative"])
+	want := map[string]interface{}{"file": "spec.json", "type": "git", "value": "https://github.com/NixOS/nixpkgs"}
+	if len(decl) != 1 || !reflect.DeepEqual(decl[0], want) {
+		t.Errorf("expected declarative %v, got %v", want, decl)
+	}
+}
+
+func TestProjectState_declarative(t *testing.T) {
+	cases := map[string]struct {
+		decl *api.DeclarativeInput
+		want []interface{}
+	}{
+		"missing": {nil, nil},
+		"empty":   {&api.DeclarativeInput{}, nil},
+		"blank": {
+			&api.DeclarativeInput{File: strPtr(""), Type: strPtr(""), Value: strPtr("")},
+			nil,
+		},
+		"partial": {
+			&api.DeclarativeInput{File: strPtr("spec.json")},
+			[]interface{}{map[string]interface{}{"file": "spec.json", "type": "", "value": ""}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			state, err := projectState("nixpkgs", &api.Project{Declarative: tc.decl})
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			if got := setList(state["declarative"]); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected declarative %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestProjectState_nil(t *testing.T) {
+	if _, err := projectState("nixpkgs", nil); err == nil {
+		t.Errorf("expected an error for an empty response")
+	}
+}
+
+func TestJobsetState_defaults(t *testing.T) {
+	state, err := jobsetState("nixpkgs", "trunk", &api.Jobset{}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := map[string]interface{}{
+		"project":             "nixpkgs",
+		"name":                "trunk",
+		"state":               "enabled",
+		"type":                "legacy",
+		"description":         "",
+		"check_interval":      defaultCheckInterval,
+		"scheduling_shares":   defaultSchedulingShares,
+		"keep_evaluations":    defaultKeepEvaluations,
+		"visible":             true,
+		"email_notifications": false,
+		"email_override":      nil,
+		"flake_uri":           nil,
+		"nix_expression":      nil,
+		"input":               nil,
+
+		"enable_dynamic_run_command": false,
+
+		"error_message":       "",
+		"error_time":          "",
+		"fetch_error_message": "",
+		"last_checked_time":   "",
+		"trigger_time":        "",
+		"start_time":          "",
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("expected %v, got %v", want, state)
+	}
+}
+
+func TestJobsetState_fields(t *testing.T) {
+	state, err := jobsetState("nixpkgs", "trunk", &api.Jobset{
+		Project:          strPtr("nixos"),
+		Name:             strPtr("staging"),
+		Enabled:          intPtr(3),
+		Type:             intPtr(0),
+		Description:      strPtr("Staging"),
+		Checkinterval:    intPtr(60),
+		Schedulingshares: intPtr(1000),
+		Keepnr:           intPtr(10),
+		Visible:          boolPtr(false),
+		Enableemail:      boolPtr(true),
+		Emailoverride:    strPtr("alice@example.com"),
+		Flake:            strPtr(""),
+		Nixexprinput:     strPtr("nixpkgs"),
+		Nixexprpath:      strPtr("release.nix"),
+
+		EnableDynamicRunCommand: boolPtr(true),
+		Inputs: &map[string]api.JobsetInput{
+			"nixpkgs": {
+				Name:             strPtr("nixpkgs"),
+				Type:             strPtr("git"),
+				Value:            strPtr("https://github.com/NixOS/nixpkgs staging"),
+				Emailresponsible: boolPtr(true),
+			},
+		},
+	}, map[string]bool{"nixpkgs": true})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for k, want := range map[string]interface{}{
+		"project":             "nixos",
+		"name":                "staging",
+		"state":               "one-at-a-time",
+		"type":                "legacy",
+		"description":         "Staging",
+		"check_interval":      60,
+		"scheduling_shares":   1000,
+		"keep_evaluations":    10,
+		"visible":             false,
+		"email_notifications": true,
+		"email_override":      "alice@example.com",
+		"flake_uri":           nil,
+
+		"enable_dynamic_run_command": true,
+	} {
+		if state[k] != want {
+			t.Errorf("expected %s to be %v, got %v", k, want, state[k])
+		}
+	}
+
+	expr := setList(state["nix_expression"])
+	if want := (map[string]interface{}{"input": "nixpkgs", "file": "release.nix"}); len(expr) != 1 || !reflect.DeepEqual(expr[0], want) {
+		t.Errorf("expected nix_expression %v, got %v", want, expr)
+	}
+
+	inputs := setList(state["input"])
+	want := map[string]interface{}{
+		"name":              "nixpkgs",
+		"type":              "git",
+		"value":             "https://github.com/NixOS/nixpkgs staging",
+		"notify_committers": true,
+		"sensitive":         true,
+	}
+	if len(inputs) != 1 || !reflect.DeepEqual(inputs[0], want) {
+		t.Errorf("expected input %v, got %v", want, inputs)
+	}
+}
+
+func TestJobsetState_status(t *testing.T) {
+	state, err := jobsetState("nixpkgs", "trunk", &api.Jobset{
+		Errormsg:        strPtr("error: attribute 'hello' missing"),
+		Errortime:       intPtr(1692921600),
+		Fetcherrormsg:   strPtr(""),
+		Lastcheckedtime: intPtr(1692925200),
+		Triggertime:     intPtr(0),
+		Starttime:       nil,
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for k, want := range map[string]interface{}{
+		"error_message":       "error: attribute 'hello' missing",
+		"error_time":          "2023-08-25T00:00:00Z",
+		"fetch_error_message": "",
+		"last_checked_time":   "2023-08-25T01:00:00Z",
+		"trigger_time":        "",
+		"start_time":          "",
+	} {
+		if state[k] != want {
+			t.Errorf("expected %s to be %v, got %v", k, want, state[k])
+		}
+	}
+}
+
+func TestJobsetState_flake(t *testing.T) {
+	state, err := jobsetState("nixpkgs", "trunk", &api.Jobset{
+		Type:         intPtr(1),
+		Flake:        strPtr("github:NixOS/nixpkgs"),
+		Nixexprinput: strPtr(""),
+		Inputs:       &map[string]api.JobsetInput{},
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state["type"] != "flake" || state["flake_uri"] != "github:NixOS/nixpkgs" {
+		t.Errorf("unexpected type %v and flake_uri %v", state["type"], state["flake_uri"])
+	}
+	if state["nix_expression"] != nil {
+		t.Errorf("expected no nix_expression, got %v", state["nix_expression"])
+	}
+	if inputs := setList(state["input"]); len(inputs) != 0 {
+		t.Errorf("expected no inputs, got %v", inputs)
+	}
+}
+
+func TestJobsetState_malformed(t *testing.T) {
+	cases := map[string]struct {
+		jobset *api.Jobset
+		errors []string
+	}{
+		"empty": {nil, []string{"empty"}},
+		"state": {&api.Jobset{Enabled: intPtr(7)}, []string{"unknown state 7"}},
+		"type":  {&api.Jobset{Type: intPtr(2)}, []string{"unknown type 2"}},
+		"input type": {
+			&api.Jobset{Inputs: &map[string]api.JobsetInput{"nixpkgs": {Value: strPtr("https://github.com/NixOS/nixpkgs")}}},
+			[]string{`input "nixpkgs" has no type`},
+		},
+		"everything": {
+			&api.Jobset{Enabled: intPtr(-1), Type: intPtr(-1)},
+			[]string{"unknown state -1", "unknown type -1"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := jobsetState("nixpkgs", "trunk", tc.jobset, nil)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			for _, msg := range tc.errors {
+				if !strings.Contains(err.Error(), msg) {
+					t.Errorf("expected the error to mention %q, got %q", msg, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFlattenInputs(t *testing.T) {
+	inputs, err := flattenInputs(map[string]api.JobsetInput{
+		"nixpkgs": {Type: strPtr("git")},
+		"config":  {Name: strPtr("config"), Type: strPtr("boolean"), Value: strPtr("true")},
+	}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	want := []interface{}{
+		map[string]interface{}{"name": "config", "type": "boolean", "value": "true", "notify_committers": false, "sensitive": false},
+		map[string]interface{}{"name": "nixpkgs", "type": "git", "value": "", "notify_committers": false, "sensitive": false},
+	}
+	if !reflect.DeepEqual(inputs, want) {
+		t.Errorf("expected %v, got %v", want, inputs)
+	}
+}
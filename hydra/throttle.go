@@ -0,0 +1,69 @@
+package hydra
+
+import (
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// throttledTransport limits how many requests to Hydra are in flight at the
+// same time, and optionally how many are sent per second, so that a large
+// configuration applied with a high -parallelism doesn't overwhelm it. Every
+// attempt counts, including retries and logging in.
+type throttledTransport struct {
+	next http.RoundTripper
+
+	// A slot is taken for the duration of each request. Nil when the number of
+	// concurrent requests is unlimited.
+	slots chan struct{}
+
+	// Nil when the rate is unlimited.
+	limiter *rate.Limiter
+}
+
+// newThrottledTransport wraps the transport, unless neither limit is set (i.e.
+// both are 0).
+func newThrottledTransport(next http.RoundTripper, maxConcurrent int, perSecond float64) http.RoundTripper {
+	if maxConcurrent <= 0 && perSecond <= 0 {
+		return next
+	}
+
+	t := &throttledTransport{next: next}
+
+	if maxConcurrent > 0 {
+		t.slots = make(chan struct{}, maxConcurrent)
+	}
+
+	if perSecond > 0 {
+		burst := int(perSecond)
+		if burst < 1 {
+			burst = 1
+		}
+		t.limiter = rate.NewLimiter(rate.Limit(perSecond), burst)
+	}
+
+	return t
+}
+
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// Hydra has done its work by the time the response headers arrive, so
+		// the slot doesn't need to be held while the body is read.
+		defer func() { <-t.slots }()
+	}
+
+	if t.limiter != nil {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.next.RoundTrip(req)
+}
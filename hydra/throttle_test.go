@@ -0,0 +1,76 @@
+package hydra
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottledTransport_maxConcurrent(t *testing.T) {
+	var inFlight, peak int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newThrottledTransport(http.DefaultTransport, 3, 0)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Errorf("err: %s", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if p := atomic.LoadInt32(&peak); p > 3 {
+		t.Errorf("expected at most 3 concurrent requests, got %d", p)
+	}
+}
+
+func TestThrottledTransport_rate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newThrottledTransport(http.DefaultTransport, 0, 20)}
+
+	// The first 20 requests are allowed as a burst, the next 10 take another
+	// half second.
+	start := time.Now()
+	for i := 0; i < 30; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		resp.Body.Close()
+	}
+
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("expected the requests to be rate limited, but they took only %s", elapsed)
+	}
+}
+
+func TestThrottledTransport_unlimited(t *testing.T) {
+	if transport := newThrottledTransport(http.DefaultTransport, 0, 0); transport != http.DefaultTransport {
+		t.Errorf("expected the transport not to be wrapped without any limits")
+	}
+}
@@ -0,0 +1,323 @@
+package hydra
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "terraform-provider-hydra"
+
+// The file the "jsonl" exporter writes to, unless HYDRA_TRACES_FILE is set.
+const defaultTracesFile = "terraform-provider-hydra-traces.jsonl"
+
+// SetupTracing installs the global OpenTelemetry tracer provider, configured
+// with the standard OTEL_* environment variables:
+//
+//   - OTEL_TRACES_EXPORTER is a comma-separated list of "otlp" (over HTTP, see
+//     OTEL_EXPORTER_OTLP_ENDPOINT and friends), "jsonl" (one JSON object per
+//     span, written to HYDRA_TRACES_FILE) and "none". Unlike in other SDKs,
+//     tracing is off by default, unless an OTLP endpoint or HYDRA_TRACES_FILE
+//     is set.
+//   - OTEL_SDK_DISABLED, OTEL_SERVICE_NAME, OTEL_RESOURCE_ATTRIBUTES,
+//     OTEL_TRACES_SAMPLER and OTEL_BSP_* work as usual.
+//
+// The returned function flushes and stops the exporters.
+func SetupTracing(ctx context.Context) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+
+	if disabled, _ := strconv.ParseBool(os.Getenv("OTEL_SDK_DISABLED")); disabled {
+		return noop, nil
+	}
+
+	var opts []sdktrace.TracerProviderOption
+	for _, name := range tracesExporters() {
+		switch name {
+		case "none":
+		case "otlp":
+			if protocol := otlpProtocol(); protocol != "http/protobuf" {
+				return noop, fmt.Errorf("unsupported OTLP protocol %q, only http/protobuf is supported", protocol)
+			}
+			exporter, err := otlptracehttp.New(ctx)
+			if err != nil {
+				return noop, fmt.Errorf("failed to create the OTLP exporter: %w", err)
+			}
+			opts = append(opts, sdktrace.WithBatcher(exporter))
+		case "jsonl":
+			path := os.Getenv("HYDRA_TRACES_FILE")
+			if path == "" {
+				path = defaultTracesFile
+			}
+			exporter, err := newFileExporter(path)
+			if err != nil {
+				return noop, err
+			}
+			// Spans are written as they end, so that they survive Terraform
+			// killing the provider.
+			opts = append(opts, sdktrace.WithSyncer(exporter))
+		default:
+			return noop, fmt.Errorf("unsupported traces exporter %q in OTEL_TRACES_EXPORTER", name)
+		}
+	}
+
+	if len(opts) == 0 {
+		return noop, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(tracerName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return noop, fmt.Errorf("failed to describe the tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(append(opts, sdktrace.WithResource(res))...)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// tracesExporters returns the names of the exporters to send traces to.
+func tracesExporters() []string {
+	if value := os.Getenv("OTEL_TRACES_EXPORTER"); value != "" {
+		var names []string
+		for _, name := range strings.Split(value, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+		return names
+	}
+
+	var names []string
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" {
+		names = append(names, "otlp")
+	}
+	if os.Getenv("HYDRA_TRACES_FILE") != "" {
+		names = append(names, "jsonl")
+	}
+	return names
+}
+
+func otlpProtocol() string {
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return "http/protobuf"
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// crudFunc is the signature of the CRUD functions of the resources.
+type crudFunc = func(context.Context, *schema.ResourceData, interface{}) diag.Diagnostics
+
+// traced wraps a CRUD function of a resource in a span, with the attributes
+// returned by attrs (e.g. the project and jobset names).
+func traced(name string, attrs func(*schema.ResourceData) []attribute.KeyValue, f crudFunc) crudFunc {
+	return func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+		ctx, span := tracer().Start(ctx, name, trace.WithAttributes(attrs(d)...))
+		defer span.End()
+
+		diags := f(ctx, d, m)
+
+		for _, diagnostic := range diags {
+			if diagnostic.Severity == diag.Error {
+				span.SetStatus(codes.Error, diagnostic.Summary)
+				break
+			}
+		}
+
+		return diags
+	}
+}
+
+// Counts the attempts at sending a request, including retries.
+type attemptsKey struct{}
+
+// startRequestSpan starts the span of a request to Hydra, under which each
+// attempt at sending it gets a span of its own.
+func startRequestSpan(req *http.Request) (*http.Request, trace.Span, *int) {
+	ctx, span := tracer().Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLPath(req.URL.Path),
+		),
+	)
+
+	attempts := new(int)
+	ctx = context.WithValue(ctx, attemptsKey{}, attempts)
+
+	return req.WithContext(ctx), span, attempts
+}
+
+// endRequestSpan records the outcome of the request in its span.
+func endRequestSpan(span trace.Span, attempts int, resp *http.Response, err error) {
+	span.SetAttributes(attribute.Int("hydra.http.attempts", attempts))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			span.SetStatus(codes.Error, resp.Status)
+		}
+	}
+	span.End()
+}
+
+// tracingTransport gives each attempt at sending a request to Hydra a span,
+// including those made by go-retryablehttp to retry it.
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resend := 0
+	if attempts, ok := req.Context().Value(attemptsKey{}).(*int); ok {
+		resend = *attempts
+		*attempts++
+	}
+
+	ctx, span := tracer().Start(req.Context(), fmt.Sprintf("HTTP %s attempt", req.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLPath(req.URL.Path),
+			semconv.HTTPRequestResendCount(resend),
+		),
+	)
+	defer span.End()
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	return resp, nil
+}
+
+// fileExporter writes each span as a line of JSON to a file, so that traces
+// can be collected without running a collector.
+type fileExporter struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+func newFileExporter(path string) (*fileExporter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the traces file: %w", err)
+	}
+
+	return &fileExporter{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+type spanRecord struct {
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	StartTime    time.Time              `json:"start_time"`
+	EndTime      time.Time              `json:"end_time"`
+	DurationMS   float64                `json:"duration_ms"`
+	Status       string                 `json:"status"`
+	StatusDesc   string                 `json:"status_description,omitempty"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Events       []eventRecord          `json:"events,omitempty"`
+	Resource     map[string]interface{} `json:"resource,omitempty"`
+}
+
+type eventRecord struct {
+	Name       string                 `json:"name"`
+	Time       time.Time              `json:"time"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+func attributeMap(attrs []attribute.KeyValue) map[string]interface{} {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	m := make(map[string]interface{}, len(attrs))
+	for _, attr := range attrs {
+		m[string(attr.Key)] = attr.Value.AsInterface()
+	}
+	return m
+}
+
+func (e *fileExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, span := range spans {
+		record := spanRecord{
+			TraceID:    span.SpanContext().TraceID().String(),
+			SpanID:     span.SpanContext().SpanID().String(),
+			Name:       span.Name(),
+			Kind:       span.SpanKind().String(),
+			StartTime:  span.StartTime(),
+			EndTime:    span.EndTime(),
+			DurationMS: float64(span.EndTime().Sub(span.StartTime())) / float64(time.Millisecond),
+			Status:     span.Status().Code.String(),
+			StatusDesc: span.Status().Description,
+			Attributes: attributeMap(span.Attributes()),
+		}
+		if span.Parent().IsValid() {
+			record.ParentSpanID = span.Parent().SpanID().String()
+		}
+		if res := span.Resource(); res != nil {
+			record.Resource = attributeMap(res.Attributes())
+		}
+		for _, event := range span.Events() {
+			record.Events = append(record.Events, eventRecord{
+				Name:       event.Name,
+				Time:       event.Time,
+				Attributes: attributeMap(event.Attributes),
+			})
+		}
+
+		if err := e.enc.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.file.Close()
+}
@@ -0,0 +1,194 @@
+package hydra
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs a tracer provider that records the spans for the
+// duration of the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	attrs := attribute.NewSet(span.Attributes()...)
+	value, _ := attrs.Value(key)
+	return value
+}
+
+func TestTraced(t *testing.T) {
+	recorder := recordSpans(t)
+
+	d := schema.TestResourceDataRaw(t, resourceHydraJobset().Schema, map[string]interface{}{
+		"project": "nixpkgs",
+		"name":    "trunk",
+	})
+
+	read := traced("hydra_jobset.read", jobsetSpanAttributes, func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+		return diag.Errorf("Hydra is down")
+	})
+	read(context.Background(), d, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "hydra_jobset.read" {
+		t.Errorf("unexpected span name %q", span.Name())
+	}
+	if span.Status().Code != codes.Error || span.Status().Description != "Hydra is down" {
+		t.Errorf("unexpected span status %v", span.Status())
+	}
+
+	for key, want := range map[attribute.Key]string{"hydra.project": "nixpkgs", "hydra.jobset": "trunk"} {
+		if got := spanAttribute(span, key); got.AsString() != want {
+			t.Errorf("expected %s to be %q, got %q", key, want, got.AsString())
+		}
+	}
+}
+
+func TestRetryableHTTPClient_attemptSpans(t *testing.T) {
+	recorder := recordSpans(t)
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	retry := retryablehttp.NewClient()
+	retry.Logger = nil
+	retry.CheckRetry = retryPolicy
+	retry.RetryWaitMin = time.Millisecond
+	retry.RetryWaitMax = time.Millisecond
+	retry.HTTPClient.Transport = &tracingTransport{next: http.DefaultTransport}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/project/nixpkgs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := (*RetryableHTTPClient)(retry).Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("expected 2 attempts and the request, got %d spans", len(spans))
+	}
+
+	request := spans[2]
+	if request.Name() != "HTTP GET" {
+		t.Errorf("unexpected request span name %q", request.Name())
+	}
+	if got := spanAttribute(request, "hydra.http.attempts"); got.AsInt64() != 2 {
+		t.Errorf("expected 2 attempts, got %d", got.AsInt64())
+	}
+
+	for i, attempt := range spans[:2] {
+		if attempt.Parent().SpanID() != request.SpanContext().SpanID() {
+			t.Errorf("attempt %d isn't a child of the request", i)
+		}
+		if got := spanAttribute(attempt, "http.request.resend_count"); got.AsInt64() != int64(i) {
+			t.Errorf("expected attempt %d to have resend count %d, got %d", i, i, got.AsInt64())
+		}
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	exporter, err := newFileExporter(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	_, child := provider.Tracer("test").Start(ctx, "child")
+	child.SetAttributes(attribute.String("hydra.project", "nixpkgs"))
+	child.End()
+	parent.End()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var records []spanRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record spanRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid line %q: %s", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(records))
+	}
+	if records[0].Name != "child" || records[0].ParentSpanID != records[1].SpanID {
+		t.Errorf("expected the child span first, under the parent: %+v", records)
+	}
+	if records[0].Attributes["hydra.project"] != "nixpkgs" {
+		t.Errorf("unexpected attributes %v", records[0].Attributes)
+	}
+}
+
+func TestTracesExporters(t *testing.T) {
+	for _, name := range []string{"OTEL_TRACES_EXPORTER", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "HYDRA_TRACES_FILE"} {
+		t.Setenv(name, "")
+	}
+
+	if got := tracesExporters(); len(got) != 0 {
+		t.Errorf("expected tracing to be off by default, got %v", got)
+	}
+
+	t.Setenv("HYDRA_TRACES_FILE", "/tmp/traces.jsonl")
+	if got := tracesExporters(); len(got) != 1 || got[0] != "jsonl" {
+		t.Errorf("expected the jsonl exporter, got %v", got)
+	}
+
+	t.Setenv("OTEL_TRACES_EXPORTER", "otlp, jsonl")
+	if got := tracesExporters(); len(got) != 2 || got[0] != "otlp" || got[1] != "jsonl" {
+		t.Errorf("expected the exporters of OTEL_TRACES_EXPORTER, got %v", got)
+	}
+}
@@ -0,0 +1,237 @@
+package hydra
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func httpSchema() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"timeout": {
+				Description:  "Timeout of a single HTTP request attempt, as a Go duration (e.g. `30s`). `0` disables the timeout.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HYDRA_HTTP_TIMEOUT", "0"),
+				ValidateFunc: validateDuration,
+			},
+			"retry_max": {
+				Description:  "Maximum number of times a failed request is retried.",
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HYDRA_HTTP_RETRY_MAX", 10),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"retry_wait_min": {
+				Description:  "Minimum time to wait between retries, as a Go duration.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HYDRA_HTTP_RETRY_WAIT_MIN", "1s"),
+				ValidateFunc: validateDuration,
+			},
+			"retry_wait_max": {
+				Description:  "Maximum time to wait between retries, as a Go duration.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HYDRA_HTTP_RETRY_WAIT_MAX", "30s"),
+				ValidateFunc: validateDuration,
+			},
+			"proxy_url": {
+				Description: "URL of the HTTP(S) proxy to use. Defaults to the proxy specified by the `HTTPS_PROXY`, `HTTP_PROXY` and `NO_PROXY` environment variables.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HYDRA_HTTP_PROXY", ""),
+			},
+			"ca_cert_file": {
+				Description: "Path to a PEM-encoded CA bundle that is trusted in addition to the system roots.",
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HYDRA_CA_CERT_FILE", ""),
+			},
+			"client_cert_file": {
+				Description:  "Path to a PEM-encoded client certificate used for mutual TLS.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HYDRA_CLIENT_CERT_FILE", ""),
+				RequiredWith: []string{"http.0.client_key_file"},
+			},
+			"client_key_file": {
+				Description:  "Path to the PEM-encoded private key belonging to `client_cert_file`.",
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("HYDRA_CLIENT_KEY_FILE", ""),
+				RequiredWith: []string{"http.0.client_cert_file"},
+			},
+			"insecure_skip_verify": {
+				Description: "Whether or not to skip verification of the server's TLS certificate. Only use this for testing.",
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("HYDRA_INSECURE_SKIP_VERIFY", false),
+			},
+		},
+	}
+}
+
+// httpConfig holds the settings of the provider's `http` block.
+type httpConfig struct {
+	Timeout            time.Duration
+	RetryMax           int
+	RetryWaitMin       time.Duration
+	RetryWaitMax       time.Duration
+	ProxyURL           string
+	CACertFile         string
+	ClientCertFile     string
+	ClientKeyFile      string
+	InsecureSkipVerify bool
+}
+
+// expandHTTPConfig converts the provider's `http` block into an httpConfig.
+// Terraform doesn't evaluate the defaults of a nested block that is absent from
+// the configuration, so in that case they are taken straight from the schema
+// (which also picks up the HYDRA_* environment variables).
+func expandHTTPConfig(l []interface{}) (*httpConfig, error) {
+	raw := make(map[string]interface{})
+
+	if len(l) > 0 && l[0] != nil {
+		raw = l[0].(map[string]interface{})
+	} else {
+		for k, s := range httpSchema().Schema {
+			v, err := s.DefaultValue()
+			if err != nil {
+				return nil, err
+			}
+			raw[k] = v
+		}
+	}
+
+	// Values that come from the environment are always strings, so normalize
+	// everything through its string representation.
+	str := func(k string) string {
+		if raw[k] == nil {
+			return ""
+		}
+		return fmt.Sprint(raw[k])
+	}
+
+	var c httpConfig
+	var err error
+
+	if c.Timeout, err = time.ParseDuration(str("timeout")); err != nil {
+		return nil, fmt.Errorf("invalid http.timeout: %w", err)
+	}
+	if c.RetryWaitMin, err = time.ParseDuration(str("retry_wait_min")); err != nil {
+		return nil, fmt.Errorf("invalid http.retry_wait_min: %w", err)
+	}
+	if c.RetryWaitMax, err = time.ParseDuration(str("retry_wait_max")); err != nil {
+		return nil, fmt.Errorf("invalid http.retry_wait_max: %w", err)
+	}
+	if c.RetryMax, err = strconv.Atoi(str("retry_max")); err != nil {
+		return nil, fmt.Errorf("invalid http.retry_max: %w", err)
+	}
+	// Without the block, the value from the environment isn't validated.
+	if c.RetryMax < 0 {
+		return nil, fmt.Errorf("invalid http.retry_max: %d is negative", c.RetryMax)
+	}
+	if s := str("insecure_skip_verify"); s != "" {
+		if c.InsecureSkipVerify, err = strconv.ParseBool(s); err != nil {
+			return nil, fmt.Errorf("invalid http.insecure_skip_verify: %w", err)
+		}
+	}
+
+	c.ProxyURL = str("proxy_url")
+	c.CACertFile = str("ca_cert_file")
+	c.ClientCertFile = str("client_cert_file")
+	c.ClientKeyFile = str("client_key_file")
+
+	if c.RetryWaitMin > c.RetryWaitMax {
+		return nil, fmt.Errorf("http.retry_wait_min (%s) must not be greater than http.retry_wait_max (%s)",
+			c.RetryWaitMin, c.RetryWaitMax)
+	}
+
+	if (c.ClientCertFile == "") != (c.ClientKeyFile == "") {
+		return nil, fmt.Errorf("http.client_cert_file and http.client_key_file must be set together")
+	}
+
+	return &c, nil
+}
+
+// apply configures the retryablehttp client, and the http.Transport underneath
+// it, according to the httpConfig.
+func (c *httpConfig) apply(retry *retryablehttp.Client) error {
+	retry.RetryMax = c.RetryMax
+	retry.RetryWaitMin = c.RetryWaitMin
+	retry.RetryWaitMax = c.RetryWaitMax
+	retry.HTTPClient.Timeout = c.Timeout
+
+	transport, ok := retry.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("unexpected HTTP transport type %T", retry.HTTPClient.Transport)
+	}
+
+	if c.ProxyURL != "" {
+		proxy, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid http.proxy_url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CACertFile != "" {
+		pem, err := os.ReadFile(c.CACertFile)
+		if err != nil {
+			return fmt.Errorf("failed to read http.ca_cert_file: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM-encoded certificates found in %s", c.CACertFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCertFile, c.ClientKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return nil
+}
+
+func validateDuration(i interface{}, k string) ([]string, []error) {
+	v, ok := i.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+
+	if _, err := time.ParseDuration(v); err != nil {
+		return nil, []error{fmt.Errorf("expected %s to be a duration (e.g. \"30s\"): %w", k, err)}
+	}
+
+	return nil, nil
+}
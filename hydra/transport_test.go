@@ -0,0 +1,81 @@
+package hydra
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpandHTTPConfig_defaults(t *testing.T) {
+	t.Setenv("HYDRA_HTTP_RETRY_MAX", "3")
+	t.Setenv("HYDRA_INSECURE_SKIP_VERIFY", "true")
+
+	c, err := expandHTTPConfig(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if c.RetryMax != 3 {
+		t.Errorf("expected retry_max from the environment to be 3, got %d", c.RetryMax)
+	}
+	if !c.InsecureSkipVerify {
+		t.Errorf("expected insecure_skip_verify from the environment to be true")
+	}
+	if c.RetryWaitMin != time.Second || c.RetryWaitMax != 30*time.Second {
+		t.Errorf("unexpected default retry waits: %s, %s", c.RetryWaitMin, c.RetryWaitMax)
+	}
+	if c.Timeout != 0 {
+		t.Errorf("expected no timeout by default, got %s", c.Timeout)
+	}
+}
+
+func TestExpandHTTPConfig_block(t *testing.T) {
+	c, err := expandHTTPConfig([]interface{}{
+		map[string]interface{}{
+			"timeout":              "10s",
+			"retry_max":            2,
+			"retry_wait_min":       "500ms",
+			"retry_wait_max":       "5s",
+			"proxy_url":            "http://proxy.example.com:3128",
+			"ca_cert_file":         "",
+			"client_cert_file":     "",
+			"client_key_file":      "",
+			"insecure_skip_verify": false,
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if c.Timeout != 10*time.Second || c.RetryMax != 2 || c.RetryWaitMin != 500*time.Millisecond {
+		t.Errorf("unexpected config: %+v", c)
+	}
+	if c.ProxyURL != "http://proxy.example.com:3128" {
+		t.Errorf("unexpected proxy_url: %s", c.ProxyURL)
+	}
+}
+
+func TestExpandHTTPConfig_invalid(t *testing.T) {
+	_, err := expandHTTPConfig([]interface{}{
+		map[string]interface{}{
+			"timeout":        "0",
+			"retry_max":      1,
+			"retry_wait_min": "1m",
+			"retry_wait_max": "1s",
+		},
+	})
+	if err == nil {
+		t.Fatal("expected retry_wait_min > retry_wait_max to be rejected")
+	}
+}
+
+func TestExpandHTTPConfig_negativeRetryMax(t *testing.T) {
+	t.Setenv("HYDRA_HTTP_RETRY_MAX", "-1")
+
+	if _, err := expandHTTPConfig(nil); err == nil {
+		t.Fatal("expected a negative retry_max to be rejected")
+	}
+
+	if _, errs := httpSchema().Schema["retry_max"].ValidateFunc(-1, "retry_max"); len(errs) == 0 {
+		t.Error("expected a negative retry_max to fail validation")
+	}
+}
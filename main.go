@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"log"
+
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
 
@@ -8,6 +11,18 @@ import (
 )
 
 func main() {
+	ctx := context.Background()
+
+	shutdown, err := hydra.SetupTracing(ctx)
+	if err != nil {
+		log.Printf("[WARN] Tracing is disabled: %s", err)
+	}
+	defer func() {
+		if err := shutdown(ctx); err != nil {
+			log.Printf("[WARN] Failed to flush traces: %s", err)
+		}
+	}()
+
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: func() *schema.Provider {
 			return hydra.Provider()